@@ -0,0 +1,301 @@
+package nested
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Конвертация объекта в каноническую JSON-строку по схеме JSON Canonicalization Scheme (RFC 8785).
+//
+// Результат не зависит от того, хранится ли число как int или float64, и совпадает с результатом
+// других реализаций JCS, поэтому подходит для вычисления хешей и подписей:
+//   - ключи словарей сортируются по кодовым единицам UTF-16;
+//   - числа записываются по правилам ECMAScript (Number.prototype.toString), например 1e+21, 1e-7, 0.000001;
+//   - в строках экранируются только кавычки, обратная косая черта и управляющие символы;
+//   - пробелы между элементами отсутствуют.
+//
+// Возвращает ошибку для NaN и бесконечностей, для целых чисел, которые нельзя точно представить в float64,
+// и для строк с некорректной кодировкой UTF-8.
+// Значения других типов (например, time.Time) сначала конвертируются через пакет encoding/json.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"b": 1.0, "a": [1e21, 0.0000001, "€"]}`)
+//
+//	nested.ToCanonicalJSON() // {"a":[1e+21,1e-7,"€"],"b":1}, nil
+func (j *Nested) ToCanonicalJSON() (string, error) {
+	buffer := &bytes.Buffer{}
+
+	if err := writeCanonicalNested(buffer, j); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// Рекурсивная запись объекта в каноническом виде.
+func writeCanonicalNested(buffer *bytes.Buffer, j *Nested) error {
+	return writeCanonicalNestedWith(buffer, j, writeCanonical)
+}
+
+// Рекурсивная запись объекта в каноническом виде со скалярными значениями, записанными через writeValue.
+func writeCanonicalNestedWith(buffer *bytes.Buffer, j *Nested, writeValue func(*bytes.Buffer, any) error) error {
+	if j == nil {
+		buffer.WriteString("null")
+		return nil
+	}
+
+	if j.IsValue() {
+		return writeValue(buffer, j.value)
+	}
+
+	if j.IsArray() {
+		buffer.WriteByte('[')
+
+		for index, element := range j.array {
+			if index > 0 {
+				buffer.WriteByte(',')
+			}
+
+			if err := writeCanonicalNestedWith(buffer, element, writeValue); err != nil {
+				return err
+			}
+		}
+
+		buffer.WriteByte(']')
+
+		return nil
+	}
+
+	keys := sortedKeys(j.nested)
+	slices.SortFunc(keys, compareUTF16)
+
+	buffer.WriteByte('{')
+
+	for index, k := range keys {
+		if index > 0 {
+			buffer.WriteByte(',')
+		}
+
+		if err := writeCanonicalString(buffer, k); err != nil {
+			return err
+		}
+
+		buffer.WriteByte(':')
+
+		if err := writeCanonicalNestedWith(buffer, j.nested[k], writeValue); err != nil {
+			return err
+		}
+	}
+
+	buffer.WriteByte('}')
+
+	return nil
+}
+
+// Рекурсивная запись скалярного значения в каноническом виде.
+//
+// Значение может само быть составным, например []any или map[string]any.
+func writeCanonical(buffer *bytes.Buffer, obj any) error {
+	switch value := obj.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case bool:
+		buffer.WriteString(strconv.FormatBool(value))
+	case string:
+		return writeCanonicalString(buffer, value)
+	case int:
+		return writeCanonicalInt(buffer, int64(value))
+	case int8:
+		return writeCanonicalInt(buffer, int64(value))
+	case int16:
+		return writeCanonicalInt(buffer, int64(value))
+	case int32:
+		return writeCanonicalInt(buffer, int64(value))
+	case int64:
+		return writeCanonicalInt(buffer, value)
+	case uint:
+		return writeCanonicalUint(buffer, uint64(value))
+	case uint8:
+		return writeCanonicalUint(buffer, uint64(value))
+	case uint16:
+		return writeCanonicalUint(buffer, uint64(value))
+	case uint32:
+		return writeCanonicalUint(buffer, uint64(value))
+	case uint64:
+		return writeCanonicalUint(buffer, value)
+	case float32:
+		return writeCanonicalNumber(buffer, float64(value))
+	case float64:
+		return writeCanonicalNumber(buffer, value)
+	case json.Number:
+		number, err := value.Float64()
+		if err != nil {
+			return err
+		}
+		return writeCanonicalNumber(buffer, number)
+	case []any:
+		buffer.WriteByte('[')
+
+		for index, element := range value {
+			if index > 0 {
+				buffer.WriteByte(',')
+			}
+
+			if err := writeCanonical(buffer, element); err != nil {
+				return err
+			}
+		}
+
+		buffer.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+
+		slices.SortFunc(keys, compareUTF16)
+
+		buffer.WriteByte('{')
+
+		for index, k := range keys {
+			if index > 0 {
+				buffer.WriteByte(',')
+			}
+
+			if err := writeCanonicalString(buffer, k); err != nil {
+				return err
+			}
+
+			buffer.WriteByte(':')
+
+			if err := writeCanonical(buffer, value[k]); err != nil {
+				return err
+			}
+		}
+
+		buffer.WriteByte('}')
+	default:
+		// прочие типы приводятся к базовым через их JSON-представление
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		var generic any
+		if err := decoder.Decode(&generic); err != nil {
+			return err
+		}
+
+		return writeCanonical(buffer, generic)
+	}
+
+	return nil
+}
+
+// Запись целого числа с проверкой, что оно точно представимо в float64.
+func writeCanonicalInt(buffer *bytes.Buffer, value int64) error {
+	// float64(math.MaxInt64) округляется до 2^63, который уже не помещается в int64
+	number := float64(value)
+	if number >= math.MaxInt64 || int64(number) != value {
+		return fmt.Errorf("integer %d cannot be represented exactly as IEEE 754 double", value)
+	}
+
+	return writeCanonicalNumber(buffer, number)
+}
+
+// Запись беззнакового целого числа с проверкой, что оно точно представимо в float64.
+func writeCanonicalUint(buffer *bytes.Buffer, value uint64) error {
+	number := float64(value)
+	if number >= math.MaxUint64 || uint64(number) != value {
+		return fmt.Errorf("integer %d cannot be represented exactly as IEEE 754 double", value)
+	}
+
+	return writeCanonicalNumber(buffer, number)
+}
+
+// Запись числа по правилам ECMAScript Number.prototype.toString.
+func writeCanonicalNumber(buffer *bytes.Buffer, value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("unsupported number %v", value)
+	}
+
+	if value == 0 {
+		buffer.WriteByte('0')
+		return nil
+	}
+
+	if value < 0 {
+		buffer.WriteByte('-')
+		value = -value
+	}
+
+	format := byte('e')
+	if value >= 1e-6 && value < 1e21 {
+		format = 'f'
+	}
+
+	formatted := strconv.FormatFloat(value, format, -1, 64)
+
+	// Go записывает порядок минимум двумя цифрами (1e-07), ECMAScript - без ведущего нуля (1e-7)
+	if exponent := strings.IndexByte(formatted, 'e'); exponent > 0 && formatted[exponent+2] == '0' {
+		formatted = formatted[:exponent+2] + formatted[exponent+3:]
+	}
+
+	buffer.WriteString(formatted)
+
+	return nil
+}
+
+// Запись строки с минимальным экранированием.
+func writeCanonicalString(buffer *bytes.Buffer, value string) error {
+	if !utf8.ValidString(value) {
+		return fmt.Errorf("invalid UTF-8 in string %q", value)
+	}
+
+	buffer.WriteByte('"')
+
+	for _, r := range value {
+		switch r {
+		case '"':
+			buffer.WriteString(`\"`)
+		case '\\':
+			buffer.WriteString(`\\`)
+		case '\b':
+			buffer.WriteString(`\b`)
+		case '\f':
+			buffer.WriteString(`\f`)
+		case '\n':
+			buffer.WriteString(`\n`)
+		case '\r':
+			buffer.WriteString(`\r`)
+		case '\t':
+			buffer.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buffer, `\u%04x`, r)
+			} else {
+				buffer.WriteRune(r)
+			}
+		}
+	}
+
+	buffer.WriteByte('"')
+
+	return nil
+}
+
+// Сравнение строк по кодовым единицам UTF-16.
+func compareUTF16(a, b string) int {
+	return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b)))
+}
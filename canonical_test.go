@@ -0,0 +1,96 @@
+package nested
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ToCanonicalJSON(t *testing.T) {
+	// примеры чисел из приложения B RFC 8785
+	numbers := []struct {
+		value any
+		json  string
+	}{
+		{0.0, "0"},
+		{math.Copysign(0, -1), "0"},
+		{5e-324, "5e-324"},
+		{-5e-324, "-5e-324"},
+		{1.7976931348623157e308, "1.7976931348623157e+308"},
+		{9007199254740992.0, "9007199254740992"},
+		{-9007199254740992.0, "-9007199254740992"},
+		{295147905179352830000.0, "295147905179352830000"},
+		{9.999999999999997e22, "9.999999999999997e+22"},
+		{1e21, "1e+21"},
+		{999999999999999700000.0, "999999999999999700000"},
+		{0.000001, "0.000001"},
+		{1e-7, "1e-7"},
+		{333333333.33333329, "333333333.3333333"},
+		{float32(0.5), "0.5"},
+		{10, "10"},
+		{int64(-9007199254740992), "-9007199254740992"},
+		{uint64(1 << 63), "9223372036854776000"},
+		{math.MinInt64, "-9223372036854776000"},
+	}
+
+	for _, c := range numbers {
+		result, err := (&Nested{isValue: true, value: c.value}).ToCanonicalJSON()
+		if assert.Nil(t, err, "%T %v", c.value, c.value) {
+			assert.Equal(t, c.json, result, "%T %v", c.value, c.value)
+		}
+	}
+
+	// int и float64 с одинаковым значением дают одинаковый результат
+	asInt := &Nested{nested: map[string]*Nested{"a": {isValue: true, value: 1}}}
+	asFloat := &Nested{nested: map[string]*Nested{"a": {isValue: true, value: 1.0}}}
+
+	intResult, _ := asInt.ToCanonicalJSON()
+	floatResult, _ := asFloat.ToCanonicalJSON()
+	assert.Equal(t, `{"a":1}`, intResult)
+	assert.Equal(t, intResult, floatResult)
+
+	// пример сортировки ключей из раздела 3.2.3 RFC 8785
+	nested := FromJSONString(`{
+		"\u20ac": "Euro Sign",
+		"\r": "Carriage Return",
+		"\ufb33": "Hebrew Letter Dalet With Dagesh",
+		"1": "One",
+		"\ud83d\ude00": "Emoji: Grinning Face",
+		"\u0080": "Control",
+		"\u00f6": "Latin Small Letter O With Diaeresis"
+	}`)
+
+	result, err := nested.ToCanonicalJSON()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\","+
+			"\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\","+
+			"\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}", result)
+	}
+
+	nested = FromJSONString(`{"b": [null, true, "a\"\\\u001f\u007f/"], "a": {}, "c": []}`)
+	nested.nested["b"].array = append(nested.nested["b"].array, nil)
+	nested.nested["d"] = &Nested{isValue: true, value: []any{map[string]any{"y": 2, "x": "1"}}}
+
+	result, err = nested.ToCanonicalJSON()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "{\"a\":{},\"b\":[null,true,\"a\\\"\\\\\\u001f\u007f/\",null],\"c\":[],\"d\":[{\"x\":\"1\",\"y\":2}]}", result)
+	}
+
+	errorCases := []struct {
+		nested *Nested
+		err    string
+	}{
+		{&Nested{isValue: true, value: math.NaN()}, "unsupported number NaN"},
+		{&Nested{isValue: true, value: math.Inf(1)}, "unsupported number +Inf"},
+		{&Nested{isValue: true, value: math.MaxInt64}, "integer 9223372036854775807 cannot be represented exactly as IEEE 754 double"},
+		{&Nested{isValue: true, value: uint64(math.MaxUint64)}, "integer 18446744073709551615 cannot be represented exactly as IEEE 754 double"},
+		{&Nested{isValue: true, value: 9007199254740993}, "integer 9007199254740993 cannot be represented exactly as IEEE 754 double"},
+		{&Nested{nested: map[string]*Nested{"\xff": {isValue: true, value: 1}}}, `invalid UTF-8 in string "\xff"`},
+	}
+
+	for _, c := range errorCases {
+		_, err := c.nested.ToCanonicalJSON()
+		assert.EqualError(t, err, c.err)
+	}
+}
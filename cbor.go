@@ -0,0 +1,742 @@
+package nested
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// Максимальная глубина вложенности при разборе CBOR без явного ограничения.
+const cborMaxDepth = 10000
+
+// Основные типы (major types) CBOR.
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// Теги CBOR, поддерживаемые при конвертации.
+const (
+	cborTagDateTime    = 0 // дата и время в виде строки RFC 3339
+	cborTagEpoch       = 1 // дата и время в виде секунд от начала эпохи
+	cborTagPositiveBig = 2 // положительное большое число
+	cborTagNegativeBig = 3 // отрицательное большое число
+)
+
+// Конвертация объекта в формат CBOR (RFC 8949).
+//
+// Целые числа записываются в форме минимальной длины, float32 и float64 - числами с плавающей точкой
+// соответствующей точности, string - текстовой строкой, []byte - байтовой строкой,
+// time.Time - строкой RFC 3339 с тегом 0 (годы 0-9999), *big.Int - целым числом или, если значение не помещается в 64 бита,
+// большим числом с тегом 2 или 3. Ключи словарей сортируются в алфавитном порядке.
+//
+// Для кодирования по правилам детерминированного кодирования RFC 8949 следует использовать [Nested.MarshalCBORDeterministic].
+// Для значений других типов вернется ошибка.
+func (j *Nested) MarshalCBOR() ([]byte, error) {
+	return cborEncoder{}.append(nil, j)
+}
+
+// Конвертация объекта в формат CBOR по правилам детерминированного кодирования (RFC 8949, раздел 4.2.1).
+//
+// В отличие от [Nested.MarshalCBOR], ключи словарей сортируются побайтово по их закодированному виду
+// (то есть сначала более короткие), а числа с плавающей точкой записываются в самой короткой форме
+// (16, 32 или 64 бита), которая сохраняет значение. Результат подходит для подписи и сравнения документов.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"bb": 1.5, "a": 1}`)
+//
+//	nested.MarshalCBORDeterministic() // a2 61 61 01 62 62 62 f9 3e 00
+func (j *Nested) MarshalCBORDeterministic() ([]byte, error) {
+	return cborEncoder{deterministic: true}.append(nil, j)
+}
+
+// Заполнение объекта из данных в формате CBOR.
+//
+// Предыдущее содержимое объекта заменяется. Правила конвертации см. в [ParseCBOR].
+// Проверяется только глубина вложенности и соответствие заявленных длин размеру данных,
+// для недоверенного ввода с дополнительными ограничениями следует использовать [ParseCBOR].
+func (j *Nested) UnmarshalCBOR(data []byte) error {
+	nested, err := ParseCBOR(data, ParseOptions{})
+	if err != nil {
+		return err
+	}
+
+	j.replace(nested)
+
+	return nil
+}
+
+// Создание объекта из данных в формате CBOR с проверкой ограничений.
+//
+// Целые числа восстанавливаются как int, значения вне диапазона int64 - как uint64 или *big.Int,
+// числа с плавающей точкой любой точности - как float64, текстовые строки - как string,
+// байтовые строки - как []byte, значения с тегами 0 и 1 - как time.Time,
+// с тегами 2 и 3 - как *big.Int. Прочие теги пропускаются, и используется значение под тегом.
+// Значения null и undefined становятся объектами-значениями nil.
+//
+// Ключи словарей должны быть текстовыми строками, повторяющиеся ключи считаются ошибкой.
+// Поддерживаются элементы неопределенной длины (indefinite-length).
+//
+// Ограничения из options применяются так же, как в [ParseJSON], при превышении возвращается [LimitError].
+// Смещение в ошибках указывается в байтах от начала данных.
+func ParseCBOR(data []byte, options ParseOptions) (*Nested, error) {
+	if options.MaxSize > 0 && int64(len(data)) > options.MaxSize {
+		return nil, &LimitError{Limit: LimitSize, Max: options.MaxSize, Offset: options.MaxSize}
+	}
+
+	decoder := cborDecoder{data: data, options: options}
+
+	nested, err := decoder.decode(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if decoder.offset != len(data) {
+		return nil, fmt.Errorf("cbor: unexpected data at offset %d", decoder.offset)
+	}
+
+	return nested, nil
+}
+
+// Кодировщик CBOR.
+type cborEncoder struct {
+	deterministic bool // детерминированное кодирование
+}
+
+// Рекурсивная запись объекта в формате CBOR.
+func (e cborEncoder) append(b []byte, j *Nested) ([]byte, error) {
+	if j == nil {
+		return append(b, 0xf6), nil
+	}
+
+	if j.IsValue() {
+		return e.appendValue(b, j.value)
+	}
+
+	if j.IsArray() {
+		b = appendCBORHead(b, cborArray, uint64(len(j.array)))
+
+		for _, element := range j.array {
+			var err error
+			if b, err = e.append(b, element); err != nil {
+				return nil, err
+			}
+		}
+
+		return b, nil
+	}
+
+	b = appendCBORHead(b, cborMap, uint64(len(j.nested)))
+
+	keys := sortedKeys(j.nested)
+	if e.deterministic {
+		// побайтовое сравнение закодированных ключей: сначала по длине, затем по содержимому
+		slices.SortFunc(keys, func(a, b string) int {
+			if len(a) != len(b) {
+				return len(a) - len(b)
+			}
+
+			return bytes.Compare([]byte(a), []byte(b))
+		})
+	}
+
+	for _, k := range keys {
+		b = appendCBORHead(b, cborText, uint64(len(k)))
+		b = append(b, k...)
+
+		var err error
+		if b, err = e.append(b, j.nested[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// Запись скалярного значения в формате CBOR.
+func (e cborEncoder) appendValue(b []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xf6), nil
+	case bool:
+		if v {
+			return append(b, 0xf5), nil
+		}
+		return append(b, 0xf4), nil
+	case int:
+		return appendCBORInt(b, int64(v)), nil
+	case int8:
+		return appendCBORInt(b, int64(v)), nil
+	case int16:
+		return appendCBORInt(b, int64(v)), nil
+	case int32:
+		return appendCBORInt(b, int64(v)), nil
+	case int64:
+		return appendCBORInt(b, v), nil
+	case uint:
+		return appendCBORHead(b, cborUint, uint64(v)), nil
+	case uint8:
+		return appendCBORHead(b, cborUint, uint64(v)), nil
+	case uint16:
+		return appendCBORHead(b, cborUint, uint64(v)), nil
+	case uint32:
+		return appendCBORHead(b, cborUint, uint64(v)), nil
+	case uint64:
+		return appendCBORHead(b, cborUint, v), nil
+	case float32:
+		if e.deterministic {
+			return appendCBORFloat(b, float64(v)), nil
+		}
+		return binary.BigEndian.AppendUint32(append(b, 0xfa), math.Float32bits(v)), nil
+	case float64:
+		if e.deterministic {
+			return appendCBORFloat(b, v), nil
+		}
+		return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(v)), nil
+	case string:
+		return append(appendCBORHead(b, cborText, uint64(len(v))), v...), nil
+	case []byte:
+		return append(appendCBORHead(b, cborBytes, uint64(len(v))), v...), nil
+	case time.Time:
+		if v.Year() < 0 || v.Year() > 9999 {
+			return nil, fmt.Errorf("cbor: time %s is out of RFC 3339 range", v)
+		}
+
+		text := v.Format(time.RFC3339Nano)
+		b = appendCBORHead(b, cborTag, cborTagDateTime)
+		return append(appendCBORHead(b, cborText, uint64(len(text))), text...), nil
+	case *big.Int:
+		if v == nil {
+			return append(b, 0xf6), nil
+		}
+		return appendCBORBigInt(b, v), nil
+	}
+
+	return nil, fmt.Errorf("cbor: unsupported type %T", value)
+}
+
+// Запись заголовка элемента: основной тип и аргумент в форме минимальной длины.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	}
+
+	return binary.BigEndian.AppendUint64(append(b, major|27), n)
+}
+
+// Запись знакового целого числа.
+func appendCBORInt(b []byte, v int64) []byte {
+	if v >= 0 {
+		return appendCBORHead(b, cborUint, uint64(v))
+	}
+
+	return appendCBORHead(b, cborNegint, uint64(-(v + 1)))
+}
+
+// Запись большого целого числа.
+//
+// Числа, помещающиеся в 64 бита, записываются как обычные целые.
+func appendCBORBigInt(b []byte, v *big.Int) []byte {
+	if v.Sign() >= 0 {
+		if v.IsUint64() {
+			return appendCBORHead(b, cborUint, v.Uint64())
+		}
+
+		data := v.Bytes()
+		b = appendCBORHead(b, cborTag, cborTagPositiveBig)
+
+		return append(appendCBORHead(b, cborBytes, uint64(len(data))), data...)
+	}
+
+	// отрицательное число n кодируется как -1 - n
+	n := new(big.Int).Neg(v)
+	n.Sub(n, big.NewInt(1))
+
+	if n.IsUint64() {
+		return appendCBORHead(b, cborNegint, n.Uint64())
+	}
+
+	data := n.Bytes()
+	b = appendCBORHead(b, cborTag, cborTagNegativeBig)
+
+	return append(appendCBORHead(b, cborBytes, uint64(len(data))), data...)
+}
+
+// Запись числа с плавающей точкой в самой короткой форме, сохраняющей значение.
+func appendCBORFloat(b []byte, v float64) []byte {
+	if math.IsNaN(v) {
+		return append(b, 0xf9, 0x7e, 0x00)
+	}
+
+	f := float32(v)
+	if float64(f) != v {
+		return binary.BigEndian.AppendUint64(append(b, 0xfb), math.Float64bits(v))
+	}
+
+	if half, ok := float32ToHalf(f); ok {
+		return binary.BigEndian.AppendUint16(append(b, 0xf9), half)
+	}
+
+	return binary.BigEndian.AppendUint32(append(b, 0xfa), math.Float32bits(f))
+}
+
+// Точная конвертация числа float32 в формат половинной точности (IEEE 754 binary16).
+//
+// Возвращает false, если значение не представимо без потери точности.
+func float32ToHalf(f float32) (uint16, bool) {
+	bits := math.Float32bits(f)
+
+	sign := uint16(bits>>16) & 0x8000
+	exponent := int((bits>>23)&0xff) - 127
+	mantissa := bits & 0x7fffff
+
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign, true
+	case exponent == 128:
+		// бесконечность (NaN обрабатывается отдельно)
+		return sign | 0x7c00, mantissa == 0
+	case exponent >= -14 && exponent <= 15:
+		if mantissa&0x1fff != 0 {
+			return 0, false
+		}
+
+		return sign | uint16(exponent+15)<<10 | uint16(mantissa>>13), true
+	case exponent >= -24 && exponent < -14:
+		// денормализованное число половинной точности
+		full := mantissa | 0x800000
+		shift := uint(-exponent - 1)
+
+		if full&(1<<shift-1) != 0 {
+			return 0, false
+		}
+
+		return sign | uint16(full>>shift), true
+	}
+
+	return 0, false
+}
+
+// Конвертация числа половинной точности в float64.
+func halfToFloat(half uint16) float64 {
+	exponent := int(half>>10) & 0x1f
+	mantissa := float64(half & 0x3ff)
+
+	var value float64
+
+	switch exponent {
+	case 0:
+		value = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mantissa+1024, exponent-25)
+	}
+
+	if half&0x8000 != 0 {
+		return -value
+	}
+
+	return value
+}
+
+// Разбор данных в формате CBOR.
+type cborDecoder struct {
+	data   []byte
+	offset int
+
+	options    ParseOptions
+	nodes      int // количество уже созданных объектов
+	containers int // текущая глубина вложенности массивов и словарей
+}
+
+// Вход в массив или словарь с проверкой глубины вложенности.
+func (d *cborDecoder) enter() error {
+	d.containers++
+	if d.options.MaxDepth > 0 && d.containers > d.options.MaxDepth {
+		return d.limitError(LimitDepth, d.options.MaxDepth)
+	}
+
+	return nil
+}
+
+// Выход из массива или словаря.
+func (d *cborDecoder) leave() {
+	d.containers--
+}
+
+// Создание ошибки превышения ограничения с текущим смещением.
+func (d *cborDecoder) limitError(limit string, max int) *LimitError {
+	return &LimitError{Limit: limit, Max: int64(max), Offset: int64(d.offset)}
+}
+
+// Чтение n байт с проверкой границ.
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.offset) {
+		return nil, fmt.Errorf("cbor: unexpected end of data at offset %d", d.offset)
+	}
+
+	result := d.data[d.offset : d.offset+int(n)]
+	d.offset += int(n)
+
+	return result, nil
+}
+
+// Чтение заголовка элемента.
+//
+// Возвращает основной тип, дополнительную информацию и аргумент.
+// Для элементов неопределенной длины (info == 31) аргумент равен нулю.
+func (d *cborDecoder) readHead() (byte, byte, uint64, error) {
+	start := d.offset
+
+	header, err := d.read(1)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	major := header[0] >> 5
+	info := header[0] & 0x1f
+
+	switch {
+	case info < 24:
+		return major, info, uint64(info), nil
+	case info <= 27:
+		data, err := d.read(1 << (info - 24))
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		var n uint64
+		for _, c := range data {
+			n = n<<8 | uint64(c)
+		}
+
+		return major, info, n, nil
+	case info == 31 && major != cborUint && major != cborNegint && major != cborTag:
+		return major, info, 0, nil
+	}
+
+	return 0, 0, 0, fmt.Errorf("cbor: invalid additional information %d at offset %d", info, start)
+}
+
+// Проверка, что следующий байт - маркер конца элемента неопределенной длины (break).
+func (d *cborDecoder) isBreak() bool {
+	if d.offset < len(d.data) && d.data[d.offset] == 0xff {
+		d.offset++
+		return true
+	}
+
+	return false
+}
+
+// Рекурсивный разбор очередного объекта.
+//
+// depth - количество массивов, словарей и тегов, в которые вложен объект.
+func (d *cborDecoder) decode(depth int) (*Nested, error) {
+	if depth > cborMaxDepth {
+		return nil, d.limitError(LimitDepth, cborMaxDepth)
+	}
+
+	d.nodes++
+	if d.options.MaxNodes > 0 && d.nodes > d.options.MaxNodes {
+		return nil, d.limitError(LimitNodes, d.options.MaxNodes)
+	}
+
+	start := d.offset
+
+	major, info, n, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if n > math.MaxInt64 {
+			return &Nested{isValue: true, value: n}, nil
+		}
+
+		return &Nested{isValue: true, value: int(n)}, nil
+	case cborNegint:
+		if n > math.MaxInt64 {
+			value := new(big.Int).SetUint64(n)
+			return &Nested{isValue: true, value: value.Neg(value).Sub(value, big.NewInt(1))}, nil
+		}
+
+		return &Nested{isValue: true, value: -1 - int(n)}, nil
+	case cborBytes, cborText:
+		data, err := d.readString(major, info, n)
+		if err != nil {
+			return nil, err
+		}
+
+		if major == cborText {
+			return &Nested{isValue: true, value: string(data)}, nil
+		}
+
+		return &Nested{isValue: true, value: data}, nil
+	case cborArray:
+		return d.decodeArray(info, n, depth)
+	case cborMap:
+		return d.decodeMap(info, n, depth)
+	case cborTag:
+		return d.decodeTag(n, depth, start)
+	}
+
+	switch info {
+	case 20:
+		return &Nested{isValue: true, value: false}, nil
+	case 21:
+		return &Nested{isValue: true, value: true}, nil
+	case 22, 23:
+		return &Nested{isValue: true, value: nil}, nil
+	case 25:
+		return &Nested{isValue: true, value: halfToFloat(uint16(n))}, nil
+	case 26:
+		return &Nested{isValue: true, value: float64(math.Float32frombits(uint32(n)))}, nil
+	case 27:
+		return &Nested{isValue: true, value: math.Float64frombits(n)}, nil
+	case 31:
+		return nil, fmt.Errorf("cbor: unexpected break at offset %d", start)
+	}
+
+	return nil, fmt.Errorf("cbor: unsupported simple value %d at offset %d", n, start)
+}
+
+// Чтение байтовой или текстовой строки, в том числе неопределенной длины.
+//
+// Текстовые строки (и каждая их часть при неопределенной длине) должны быть в кодировке UTF-8.
+func (d *cborDecoder) readString(major byte, info byte, n uint64) ([]byte, error) {
+	if info != 31 {
+		if d.options.MaxStringLength > 0 && n > uint64(d.options.MaxStringLength) {
+			return nil, d.limitError(LimitStringLength, d.options.MaxStringLength)
+		}
+
+		data, err := d.readText(major, n)
+		if err != nil {
+			return nil, err
+		}
+
+		return append([]byte{}, data...), nil
+	}
+
+	result := []byte{}
+
+	for !d.isBreak() {
+		chunkStart := d.offset
+
+		chunkMajor, chunkInfo, chunkLength, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+
+		if chunkMajor != major || chunkInfo == 31 {
+			return nil, fmt.Errorf("cbor: invalid indefinite-length string chunk at offset %d", chunkStart)
+		}
+
+		if d.options.MaxStringLength > 0 && uint64(len(result))+chunkLength > uint64(d.options.MaxStringLength) {
+			return nil, d.limitError(LimitStringLength, d.options.MaxStringLength)
+		}
+
+		data, err := d.readText(major, chunkLength)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, data...)
+	}
+
+	return result, nil
+}
+
+// Чтение n байт строки с проверкой кодировки UTF-8 для текстовых строк.
+func (d *cborDecoder) readText(major byte, n uint64) ([]byte, error) {
+	start := d.offset
+
+	data, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if major == cborText && !utf8.Valid(data) {
+		return nil, fmt.Errorf("cbor: invalid UTF-8 text string at offset %d", start)
+	}
+
+	return data, nil
+}
+
+// Разбор массива определенной или неопределенной длины.
+func (d *cborDecoder) decodeArray(info byte, n uint64, depth int) (*Nested, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	// длина проверяется до выделения памяти под элементы
+	if info != 31 && d.options.MaxArrayLength > 0 && n > uint64(d.options.MaxArrayLength) {
+		return nil, d.limitError(LimitArrayLength, d.options.MaxArrayLength)
+	}
+
+	// каждый элемент занимает хотя бы один байт
+	if info != 31 && n > uint64(len(d.data)-d.offset) {
+		return nil, fmt.Errorf("cbor: unexpected end of data at offset %d", d.offset)
+	}
+
+	array := make([]*Nested, 0, n)
+
+	for index := uint64(0); info == 31 || index < n; index++ {
+		if info == 31 && d.isBreak() {
+			break
+		}
+
+		if d.options.MaxArrayLength > 0 && len(array) >= d.options.MaxArrayLength {
+			return nil, d.limitError(LimitArrayLength, d.options.MaxArrayLength)
+		}
+
+		element, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		array = append(array, element)
+	}
+
+	return &Nested{isArray: true, array: array}, nil
+}
+
+// Разбор словаря определенной или неопределенной длины.
+func (d *cborDecoder) decodeMap(info byte, n uint64, depth int) (*Nested, error) {
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	// количество ключей проверяется до выделения памяти под словарь
+	if info != 31 && d.options.MaxKeys > 0 && n > uint64(d.options.MaxKeys) {
+		return nil, d.limitError(LimitKeys, d.options.MaxKeys)
+	}
+
+	// каждая пара занимает хотя бы два байта
+	if info != 31 && n > uint64(len(d.data)-d.offset)/2 {
+		return nil, fmt.Errorf("cbor: unexpected end of data at offset %d", d.offset)
+	}
+
+	nested := make(map[string]*Nested, n)
+
+	for index := uint64(0); info == 31 || index < n; index++ {
+		if info == 31 && d.isBreak() {
+			break
+		}
+
+		if d.options.MaxKeys > 0 && len(nested) >= d.options.MaxKeys {
+			return nil, d.limitError(LimitKeys, d.options.MaxKeys)
+		}
+
+		keyOffset := d.offset
+
+		major, keyInfo, keyLength, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+
+		if major != cborText {
+			return nil, fmt.Errorf("cbor: map key at offset %d must be a text string", keyOffset)
+		}
+
+		key, err := d.readString(major, keyInfo, keyLength)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := nested[string(key)]; ok {
+			return nil, fmt.Errorf("cbor: duplicate map key '%s' at offset %d", key, keyOffset)
+		}
+
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		nested[string(key)] = value
+	}
+
+	return &Nested{nested: nested}, nil
+}
+
+// Разбор значения с тегом.
+func (d *cborDecoder) decodeTag(tag uint64, depth int, start int) (*Nested, error) {
+	// тег и значение под ним образуют один объект
+	d.nodes--
+
+	content, err := d.decode(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case cborTagDateTime:
+		if text, ok := content.value.(string); ok {
+			value, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return nil, fmt.Errorf("cbor: invalid date/time at offset %d: %s", start, err.Error())
+			}
+
+			return &Nested{isValue: true, value: value}, nil
+		}
+	case cborTagEpoch:
+		var value time.Time
+		ok := false
+
+		switch seconds := content.value.(type) {
+		case int:
+			value, ok = time.Unix(int64(seconds), 0).UTC(), true
+		case float64:
+			if !math.IsNaN(seconds) && !math.IsInf(seconds, 0) {
+				integer, fraction := math.Modf(seconds)
+				value, ok = time.Unix(int64(integer), int64(fraction*1e9)).UTC(), true
+			}
+		}
+
+		// время за пределами диапазона RFC 3339 нельзя записать обратно
+		if ok && value.Year() >= 0 && value.Year() <= 9999 {
+			return &Nested{isValue: true, value: value}, nil
+		}
+	case cborTagPositiveBig, cborTagNegativeBig:
+		if data, ok := content.value.([]byte); ok {
+			value := new(big.Int).SetBytes(data)
+			if tag == cborTagNegativeBig {
+				value.Neg(value).Sub(value, big.NewInt(1))
+			}
+
+			return &Nested{isValue: true, value: value}, nil
+		}
+	default:
+		return content, nil
+	}
+
+	return nil, fmt.Errorf("cbor: invalid content for tag %d at offset %d", tag, start)
+}
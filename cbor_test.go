@@ -0,0 +1,317 @@
+package nested
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Декодирование шестнадцатеричной строки для тестов.
+func fromHex(s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+func Test_MarshalCBOR(t *testing.T) {
+	bigValue, _ := new(big.Int).SetString("18446744073709551616", 10)
+	negativeBigValue, _ := new(big.Int).SetString("-18446744073709551617", 10)
+	minUint64Value, _ := new(big.Int).SetString("-18446744073709551616", 10)
+
+	// примеры из приложения A RFC 8949
+	cases := []struct {
+		value         any
+		data          string
+		deterministic string
+	}{
+		{0, "00", ""},
+		{23, "17", ""},
+		{24, "1818", ""},
+		{uint16(1000), "1903e8", ""},
+		{int64(1000000000000), "1b000000e8d4a51000", ""},
+		{uint64(math.MaxUint64), "1bffffffffffffffff", ""},
+		{bigValue, "c249010000000000000000", ""},
+		{negativeBigValue, "c349010000000000000000", ""},
+		{minUint64Value, "3bffffffffffffffff", ""},
+		{big.NewInt(-1), "20", ""},
+		{-1000, "3903e7", ""},
+		{1.5, "fb3ff8000000000000", "f93e00"},
+		{float32(100000.0), "fa47c35000", ""},
+		{1.1, "fb3ff199999999999a", ""},
+		{65504.0, "fb40effc0000000000", "f97bff"},
+		{5.960464477539063e-8, "fb3e70000000000000", "f90001"},
+		{-4.0, "fbc010000000000000", "f9c400"},
+		{0.0, "fb0000000000000000", "f90000"},
+		{math.Inf(1), "fb7ff0000000000000", "f97c00"},
+		{math.NaN(), "fb7ff8000000000001", "f97e00"},
+		{false, "f4", ""},
+		{true, "f5", ""},
+		{nil, "f6", ""},
+		{"", "60", ""},
+		{"IETF", "6449455446", ""},
+		{"ü", "62c3bc", ""},
+		{[]byte{1, 2, 3, 4}, "4401020304", ""},
+		{time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC), "c074323031332d30332d32315432303a30343a30305a", ""},
+	}
+
+	for _, c := range cases {
+		nested := Nested{isValue: true, value: c.value}
+
+		if f, ok := c.value.(float64); ok && math.IsNaN(f) {
+			nested.value = math.Float64frombits(0x7ff8000000000001)
+		}
+
+		data, err := nested.MarshalCBOR()
+		if assert.Nil(t, err) {
+			assert.Equal(t, c.data, hex.EncodeToString(data), "%T %v", c.value, c.value)
+		}
+
+		if c.deterministic == "" {
+			c.deterministic = c.data
+		}
+
+		data, err = nested.MarshalCBORDeterministic()
+		if assert.Nil(t, err) {
+			assert.Equal(t, c.deterministic, hex.EncodeToString(data), "%T %v", c.value, c.value)
+		}
+	}
+
+	nested := FromJSONString(`{"bb": 1.5, "a": [1, 2], "c": {}}`)
+
+	data, err := nested.MarshalCBOR()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a36161820102626262fb3ff80000000000006163a0", hex.EncodeToString(data))
+	}
+
+	// в детерминированном режиме более короткие ключи идут первыми
+	data, err = nested.MarshalCBORDeterministic()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a361618201026163a0626262f93e00", hex.EncodeToString(data))
+	}
+
+	_, err = (&Nested{isValue: true, value: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}).MarshalCBOR()
+	assert.EqualError(t, err, "cbor: time 10000-01-01 00:00:00 +0000 UTC is out of RFC 3339 range")
+
+	_, err = (&Nested{isValue: true, value: struct{}{}}).MarshalCBOR()
+	assert.EqualError(t, err, "cbor: unsupported type struct {}")
+}
+
+func Test_ParseCBOR(t *testing.T) {
+	bigValue, _ := new(big.Int).SetString("18446744073709551616", 10)
+	negativeBigValue, _ := new(big.Int).SetString("-18446744073709551617", 10)
+	minUint64Value, _ := new(big.Int).SetString("-18446744073709551616", 10)
+
+	// примеры из приложения A RFC 8949
+	cases := []struct {
+		data  string
+		value any
+	}{
+		{"00", 0},
+		{"1818", 24},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"c249010000000000000000", bigValue},
+		{"3bffffffffffffffff", minUint64Value},
+		{"c349010000000000000000", negativeBigValue},
+		{"3903e7", -1000},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f90001", 5.960464477539063e-8},
+		{"f9c400", -4.0},
+		{"f9fc00", math.Inf(-1)},
+		{"fa47c35000", 100000.0},
+		{"fb3ff199999999999a", 1.1},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"f7", nil},
+		{"6449455446", "IETF"},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"c074323031332d30332d32315432303a30343a30305a", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"c11a514b67b0", time.Date(2013, 3, 21, 20, 4, 0, 0, time.UTC)},
+		{"c1fb41d452d9ec200000", time.Date(2013, 3, 21, 20, 4, 0, 500000000, time.UTC)},
+		{"d74401020304", []byte{1, 2, 3, 4}},
+		{"d818456449455446", []byte("dIETF")},
+	}
+
+	for _, c := range cases {
+		nested, err := ParseCBOR(fromHex(c.data), ParseOptions{})
+		if assert.Nil(t, err, c.data) {
+			assert.Equal(t, &Nested{isValue: true, value: c.value}, nested, c.data)
+		}
+	}
+
+	nested, err := ParseCBOR(fromHex("f97e00"), ParseOptions{})
+	if assert.Nil(t, err) {
+		value, _ := nested.GetValue()
+		assert.True(t, math.IsNaN(value.(float64)))
+	}
+
+	nested, err = ParseCBOR(fromHex("bf61610161629f0203ffff"), ParseOptions{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, FromJSONString(`{"a": 1, "b": [2, 3]}`), nested)
+	}
+
+	nested, err = ParseCBOR(fromHex("a26161016162820203"), ParseOptions{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, FromJSONString(`{"a": 1, "b": [2, 3]}`), nested)
+	}
+
+	original := testNested()
+	original.nested["types"] = &Nested{nested: map[string]*Nested{
+		"bytes":  {isValue: true, value: []byte{1, 2}},
+		"time":   {isValue: true, value: time.Date(2024, 3, 18, 10, 0, 0, 5, time.UTC)},
+		"big":    {isValue: true, value: bigValue},
+		"float":  {isValue: true, value: 0.1},
+		"nil":    {isValue: true, value: nil},
+		"array":  {isArray: true, array: []*Nested{}},
+		"nested": {nested: map[string]*Nested{}},
+	}}
+
+	data, err := original.MarshalCBORDeterministic()
+	if assert.Nil(t, err) {
+		result := Nested{isValue: true, value: "previous"}
+
+		if assert.Nil(t, result.UnmarshalCBOR(data)) {
+			assert.Equal(t, original, result)
+		}
+
+		// подписчики объекта сохраняются и получают событие замены
+		observed := FromJSONString(`{"a": 1}`)
+
+		events := []ChangeEvent{}
+		observed.Subscribe(func(event ChangeEvent) {
+			events = append(events, event)
+		})
+
+		if assert.Nil(t, observed.UnmarshalCBOR(data)) {
+			assert.Nil(t, observed.SetValue(2, "int"))
+
+			if assert.Len(t, events, 2) {
+				assert.Equal(t, ChangeSet, events[0].Op)
+				assert.Equal(t, []string{}, events[0].Path)
+				assert.Equal(t, []string{"int"}, events[1].Path)
+			}
+		}
+	}
+
+	errorCases := []struct {
+		data string
+		err  string
+	}{
+		{"", "cbor: unexpected end of data at offset 0"},
+		{"0102", "cbor: unexpected data at offset 1"},
+		{"1c", "cbor: invalid additional information 28 at offset 0"},
+		{"1f", "cbor: invalid additional information 31 at offset 0"},
+		{"ff", "cbor: unexpected break at offset 0"},
+		{"9b00000000ffffffff", "cbor: unexpected end of data at offset 9"},
+		{"6361", "cbor: unexpected end of data at offset 1"},
+		{"a10101", "cbor: map key at offset 1 must be a text string"},
+		{"a2616101616102", "cbor: duplicate map key 'a' at offset 4"},
+		{"5f6161ff", "cbor: invalid indefinite-length string chunk at offset 1"},
+		{"62c328", "cbor: invalid UTF-8 text string at offset 1"},
+		{"7f61c3ff", "cbor: invalid UTF-8 text string at offset 2"},
+		{"a161ff01", "cbor: invalid UTF-8 text string at offset 2"},
+		{"c06161", "cbor: invalid date/time at offset 0: parsing time \"a\" as \"2006-01-02T15:04:05.999999999Z07:00\": cannot parse \"a\" as \"2006\""},
+		{"c26161", "cbor: invalid content for tag 2 at offset 0"},
+		{"f820", "cbor: unsupported simple value 32 at offset 0"},
+		{"c11b0000400000000000", "cbor: invalid content for tag 1 at offset 0"},
+	}
+
+	for _, c := range errorCases {
+		_, err := ParseCBOR(fromHex(c.data), ParseOptions{})
+		assert.EqualError(t, err, c.err, c.data)
+	}
+
+	input, _ := FromJSONString(`{"a": [1, 2, {"b": "string"}], "c": {"d": {}}}`).MarshalCBOR()
+
+	limitCases := []struct {
+		options ParseOptions
+		err     string
+	}{
+		{ParseOptions{MaxSize: int64(len(input))}, ""},
+		{ParseOptions{MaxSize: 20}, "size limit 20 exceeded at offset 20"},
+		{ParseOptions{MaxDepth: 3}, ""},
+		{ParseOptions{MaxDepth: 2}, "depth limit 2 exceeded at offset 7"},
+		{ParseOptions{MaxNodes: 8}, ""},
+		{ParseOptions{MaxNodes: 5}, "nodes limit 5 exceeded at offset 9"},
+		{ParseOptions{MaxStringLength: 6}, ""},
+		{ParseOptions{MaxStringLength: 5}, "string length limit 5 exceeded at offset 10"},
+		{ParseOptions{MaxArrayLength: 3}, ""},
+		{ParseOptions{MaxArrayLength: 2}, "array length limit 2 exceeded at offset 4"},
+		{ParseOptions{MaxKeys: 2}, ""},
+		{ParseOptions{MaxKeys: 1}, "keys limit 1 exceeded at offset 1"},
+	}
+
+	for _, c := range limitCases {
+		nested, err := ParseCBOR(input, c.options)
+
+		if c.err == "" {
+			if assert.Nil(t, err) {
+				assert.Equal(t, FromJSONString(`{"a": [1, 2, {"b": "string"}], "c": {"d": {}}}`), nested)
+			}
+			continue
+		}
+
+		var limitErr *LimitError
+		if assert.True(t, errors.As(err, &limitErr), c.err) {
+			assert.EqualError(t, err, c.err)
+		}
+	}
+
+	// заявленная длина проверяется до выделения памяти
+	_, err = ParseCBOR(fromHex("9b00ffffffffffffff01"), ParseOptions{MaxArrayLength: 10})
+	assert.EqualError(t, err, "array length limit 10 exceeded at offset 9")
+
+	_, err = ParseCBOR(fromHex("bb00ffffffffffffff616101"), ParseOptions{MaxKeys: 10})
+	assert.EqualError(t, err, "keys limit 10 exceeded at offset 9")
+
+	_, err = ParseCBOR(fromHex("9b00ffffffffffffff01"), ParseOptions{})
+	assert.EqualError(t, err, "cbor: unexpected end of data at offset 9")
+}
+
+func FuzzCBOR(f *testing.F) {
+	nested := testNested()
+	data, _ := nested.MarshalCBOR()
+	f.Add(data)
+
+	for _, seed := range []string{"f97e00", "c249010000000000000000", "bf61610161629f0203ffff", "5f42010243030405ff", "c1fb41d452d9ec200000"} {
+		f.Add(fromHex(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		first, err := ParseCBOR(data, ParseOptions{MaxNodes: 1000})
+		if err != nil {
+			return
+		}
+
+		encoded, err := first.MarshalCBORDeterministic()
+		if err != nil {
+			t.Fatalf("marshal decoded value: %v", err)
+		}
+
+		second, err := ParseCBOR(encoded, ParseOptions{})
+		if err != nil {
+			t.Fatalf("unmarshal encoded value: %v", err)
+		}
+
+		reencoded, err := second.MarshalCBORDeterministic()
+		if err != nil {
+			t.Fatalf("marshal decoded value: %v", err)
+		}
+
+		if !bytes.Equal(encoded, reencoded) {
+			t.Fatalf("round trip mismatch: %x != %x", encoded, reencoded)
+		}
+	})
+}
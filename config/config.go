@@ -0,0 +1,225 @@
+// Загрузка конфигурации из нескольких источников на основе [nested.Nested].
+//
+// Источники (значения по умолчанию, файлы JSON, YAML и TOML, переменные окружения, флаги командной строки)
+// применяются по порядку, каждый следующий имеет приоритет над предыдущими. Объекты ключ-значение
+// объединяются рекурсивно, значения и массивы заменяются целиком.
+//
+// Для каждого значения сохраняется источник, из которого оно получено, а результат доступен только для чтения.
+//
+// Пример использования:
+//
+//	flags := flag.NewFlagSet("app", flag.ExitOnError)
+//	flags.Int("port", 0, "listen port")
+//	flags.Parse(os.Args[1:])
+//
+//	cfg, err := config.Load(
+//		config.Static("defaults", nested.FromJSONString(`{"server": {"host": "0.0.0.0", "port": 8080}}`)),
+//		config.OptionalFile("/etc/app/config.yaml"),
+//		config.Env("APP_", "__"),
+//		config.Flags(flags, map[string][]string{"port": {"server", "port"}}),
+//	)
+//
+//	cfg.GetValue("server", "port") // 8080, если порт не переопределен
+//	cfg.Origin("server", "port")   // "defaults", true
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	nested "github.com/NGRsoftlab/ngr-nested"
+)
+
+// Разделитель ключей во внутреннем представлении цепочек, не встречающийся в ключах на практике.
+const pathSeparator = "\x00"
+
+// Загруженная конфигурация.
+//
+// Доступна только для чтения: все функции возвращают копии объектов, поэтому их изменение
+// не влияет на конфигурацию.
+type Config struct {
+	data    *nested.Nested
+	origins map[string]string // имя источника для каждой цепочки ключей до значения
+}
+
+// Загрузка конфигурации из источников.
+//
+// Источники применяются по порядку, значения из более поздних источников перезаписывают значения из более ранних.
+// Объекты ключ-значение объединяются рекурсивно, а значения, массивы и объекты, заменяющие значение другого вида,
+// заменяются целиком.
+//
+// Каждый источник должен возвращать объект вида ключ-значение. Ошибки источников возвращаются с именем источника.
+func Load(sources ...Source) (*Config, error) {
+	data := make([]*nested.Nested, 0, len(sources))
+
+	for _, source := range sources {
+		loaded, err := loadSource(source)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, loaded)
+	}
+
+	return build(sources, data), nil
+}
+
+// Загрузка объекта из источника с проверкой, что он имеет вид ключ-значение.
+//
+// Для пустого источника возвращается nil.
+func loadSource(source Source) (*nested.Nested, error) {
+	data, err := source.Load()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source.Name(), err)
+	}
+
+	if data == nil {
+		return nil, nil
+	}
+
+	if data.IsValue() {
+		return nil, fmt.Errorf("%s: is value", source.Name())
+	}
+
+	if data.IsArray() {
+		return nil, fmt.Errorf("%s: is array", source.Name())
+	}
+
+	return data, nil
+}
+
+// Объединение объектов, загруженных из источников, в конфигурацию.
+//
+// data[i] - объект, загруженный из sources[i]. Объекты не изменяются.
+func build(sources []Source, data []*nested.Nested) *Config {
+	config := &Config{
+		data:    &nested.Nested{},
+		origins: map[string]string{},
+	}
+
+	for index, source := range sources {
+		if data[index] == nil {
+			continue
+		}
+
+		_, fold := source.(*envSource)
+		config.merge(config.data, data[index], []string{}, source.Name(), fold)
+	}
+
+	return config
+}
+
+// Рекурсивное объединение объекта из источника с загруженной конфигурацией.
+//
+// dst и src - объекты ключ-значение, path - цепочка ключей до них. При fold ключи источника
+// сопоставляются с ключами конфигурации без учета регистра (см. [nested.Nested.FoldKeys]).
+func (c *Config) merge(dst, src *nested.Nested, path []string, source string, fold bool) {
+	srcMap, _ := src.GetMap()
+	dstMap, _ := dst.GetMap()
+
+	for k, value := range srcMap {
+		if fold {
+			k = dst.FoldKeys(k)[0]
+		}
+
+		keyPath := append(slices.Clone(path), k)
+		existing, ok := dstMap[k]
+
+		if value != nil && value.IsNested() {
+			if !ok || existing == nil || !existing.IsNested() {
+				c.removeOrigins(keyPath)
+				existing = &nested.Nested{}
+				dst.Set(existing, k)
+
+				if value.IsEmpty() {
+					c.origins[strings.Join(keyPath, pathSeparator)] = source
+				}
+			} else if !value.IsEmpty() {
+				// пустой объект из предыдущего источника перестает быть самостоятельным значением
+				delete(c.origins, strings.Join(keyPath, pathSeparator))
+			}
+
+			c.merge(existing, value, keyPath, source, fold)
+
+			continue
+		}
+
+		c.removeOrigins(keyPath)
+		dst.Set(value.Clone(), k)
+		c.origins[strings.Join(keyPath, pathSeparator)] = source
+	}
+}
+
+// Удаление источников для цепочки ключей и всех вложенных в нее.
+func (c *Config) removeOrigins(path []string) {
+	prefix := strings.Join(path, pathSeparator)
+
+	for k := range c.origins {
+		if k == prefix || strings.HasPrefix(k, prefix+pathSeparator) {
+			delete(c.origins, k)
+		}
+	}
+}
+
+// Получение копии вложенного объекта по цепочке ключей.
+//
+// Без ключей возвращается копия всей конфигурации.
+func (c *Config) Get(keys ...string) (*nested.Nested, error) {
+	if len(keys) == 0 {
+		return c.data.Clone(), nil
+	}
+
+	value, err := c.data.Get(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	return value.Clone(), nil
+}
+
+// Получение скалярного значения по цепочке ключей, см. [nested.Nested.GetValue].
+func (c *Config) GetValue(keys ...string) (any, error) {
+	return c.data.GetValue(keys...)
+}
+
+// Получение копии вложенного массива по цепочке ключей, см. [nested.Nested.GetArray].
+func (c *Config) GetArray(keys ...string) ([]*nested.Nested, error) {
+	array, err := c.data.GetArray(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*nested.Nested, 0, len(array))
+	for _, element := range array {
+		result = append(result, element.Clone())
+	}
+
+	return result, nil
+}
+
+// Получение имени источника, из которого получено значение по цепочке ключей.
+//
+// Источник хранится для значений, массивов и пустых объектов ключ-значение.
+// Для объектов, собранных из нескольких источников, и отсутствующих ключей вернется false.
+func (c *Config) Origin(keys ...string) (string, bool) {
+	source, ok := c.origins[strings.Join(keys, pathSeparator)]
+	return source, ok
+}
+
+// Получение источников всех значений.
+//
+// Ключ словаря - цепочка ключей до значения, объединенная через точку.
+func (c *Config) Origins() map[string]string {
+	result := make(map[string]string, len(c.origins))
+	for k, source := range c.origins {
+		result[strings.ReplaceAll(k, pathSeparator, ".")] = source
+	}
+
+	return result
+}
+
+// Конвертация конфигурации в JSON-строку, см. [nested.Nested.ToJSONString].
+func (c *Config) ToJSONString() string {
+	return c.data.ToJSONString()
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nested "github.com/NGRsoftlab/ngr-nested"
+)
+
+func Test_Load(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "config.yaml")
+	assert.Nil(t, os.WriteFile(yamlPath, []byte("server:\n  host: example.com\n  tls: {}\ndb:\n  hosts: [a, b]\n"), 0o600))
+
+	tomlPath := filepath.Join(dir, "override.toml")
+	assert.Nil(t, os.WriteFile(tomlPath, []byte("[db]\nhosts = [\"c\"]\nname = \"app\"\nmaxConns = 5\n"), 0o600))
+
+	t.Setenv("CONFIG_TEST_SERVER__PORT", "9000")
+	t.Setenv("CONFIG_TEST_LOG", "debug")
+	t.Setenv("CONFIG_TEST_DB__MAXCONNS", "10")
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("log", "info", "")
+	flags.Bool("tls", false, "")
+	assert.Nil(t, flags.Parse([]string{"-log", "warn"}))
+
+	defaults := nested.FromJSONString(`{"server": {"host": "0.0.0.0", "port": 8080, "tls": {"enabled": false}}, "log": {"level": "info"}}`)
+
+	config, err := Load(
+		Static("defaults", defaults),
+		File(yamlPath),
+		OptionalFile(filepath.Join(dir, "missing.json")),
+		File(tomlPath),
+		Env("CONFIG_TEST_", "__"),
+		Flags(flags, map[string][]string{"log": {"log"}, "tls": {"server", "tls"}}),
+	)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, nested.FromJSONString(`{
+		"server": {"host": "example.com", "port": 9000, "tls": {"enabled": false}},
+		"db": {"hosts": ["c"], "name": "app", "maxConns": 10},
+		"log": "warn"
+	}`).ToObject(), config.data.ToObject())
+
+	assert.Equal(t, map[string]string{
+		"server.host":        yamlPath,
+		"server.port":        "env",
+		"server.tls.enabled": "defaults",
+		"db.hosts":           tomlPath,
+		"db.name":            tomlPath,
+		"db.maxConns":        "env",
+		"log":                "flags",
+	}, config.Origins())
+
+	origin, ok := config.Origin("server", "port")
+	assert.True(t, ok)
+	assert.Equal(t, "env", origin)
+
+	_, ok = config.Origin("server")
+	assert.False(t, ok)
+
+	// результат доступен только для чтения
+	server, err := config.Get("server")
+	if assert.Nil(t, err) {
+		server.SetValue("changed", "host")
+	}
+
+	value, err := config.GetValue("server", "host")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "example.com", value)
+	}
+
+	array, err := config.GetArray("db", "hosts")
+	if assert.Nil(t, err) {
+		array[0].SetValue("changed")
+	}
+
+	all, err := config.Get()
+	if assert.Nil(t, err) {
+		assert.Equal(t, config.data, all)
+		assert.NotSame(t, config.data, all)
+	}
+
+	defaults.SetValue("changed", "log", "level")
+	assert.Equal(t, `{"db":{"hosts":["c"],"maxConns":10,"name":"app"},"log":"warn","server":{"host":"example.com","port":9000,"tls":{"enabled":false}}}`,
+		config.ToJSONString())
+}
+
+func Test_LoadEmptyObjects(t *testing.T) {
+	config, err := Load(
+		Static("first", nested.FromJSONString(`{"a": {}, "b": {"c": 1}, "d": {}}`)),
+		Static("second", nested.FromJSONString(`{"a": {"x": 1}, "b": {}, "d": {}}`)),
+		Static("empty", nil),
+	)
+	if assert.Nil(t, err) {
+		assert.Equal(t, map[string]string{"a.x": "second", "b.c": "first", "d": "first"}, config.Origins())
+	}
+}
+
+func Test_LoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	invalidPath := filepath.Join(dir, "invalid.json")
+	assert.Nil(t, os.WriteFile(invalidPath, []byte(`{"a": `), 0o600))
+
+	iniPath := filepath.Join(dir, "config.ini")
+	assert.Nil(t, os.WriteFile(iniPath, []byte(``), 0o600))
+
+	missingPath := filepath.Join(dir, "missing.json")
+
+	cases := []struct {
+		source Source
+		err    string
+	}{
+		{File(invalidPath), invalidPath + ": unexpected EOF"},
+		{File(iniPath), iniPath + ": unsupported file extension '.ini'"},
+		{File(missingPath), missingPath + ": open " + missingPath + ": no such file or directory"},
+		{Static("value", nested.FromJSONString(`1`)), "value: is value"},
+		{Static("array", nested.FromJSONString(`[1]`)), "array: is array"},
+		{Env("CONFIG_TEST_", ""), "env: separator must not be empty"},
+		{Flags(flag.NewFlagSet("test", flag.ContinueOnError), map[string][]string{"a": {"a"}}), "flags: flag 'a' is not defined"},
+	}
+
+	for _, c := range cases {
+		_, err := Load(c.source)
+		assert.EqualError(t, err, c.err)
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	nested "github.com/NGRsoftlab/ngr-nested"
+)
+
+// Источник конфигурации.
+//
+// Load должен возвращать объект вида ключ-значение. Name используется в ошибках и для указания
+// происхождения значений (см. [Config.Origin]).
+type Source interface {
+	Name() string
+	Load() (*nested.Nested, error)
+}
+
+// Источник из файла.
+type fileSource struct {
+	path     string
+	optional bool
+}
+
+// Источник из файла JSON, YAML или TOML.
+//
+// Формат определяется по расширению: .json, .yaml, .yml или .toml. Именем источника является путь к файлу.
+func File(path string) Source {
+	return &fileSource{path: path}
+}
+
+// Источник из необязательного файла.
+//
+// В отличие от [File], отсутствие файла не считается ошибкой, источник в этом случае пустой.
+func OptionalFile(path string) Source {
+	return &fileSource{path: path, optional: true}
+}
+
+func (s *fileSource) Name() string {
+	return s.path
+}
+
+// Состояние файла для определения изменений без чтения, см. [Watcher.Reload].
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime int64 // время изменения в наносекундах
+}
+
+// Получение состояния файла.
+//
+// Для отсутствующего необязательного файла возвращается состояние с exists равным false.
+func (s *fileSource) stamp() (fileStamp, error) {
+	info, err := os.Stat(s.path)
+	if s.optional && errors.Is(err, fs.ErrNotExist) {
+		return fileStamp{}, nil
+	}
+
+	if err != nil {
+		return fileStamp{}, err
+	}
+
+	return fileStamp{exists: true, size: info.Size(), modTime: info.ModTime().UnixNano()}, nil
+}
+
+func (s *fileSource) Load() (*nested.Nested, error) {
+	data, err := os.ReadFile(s.path)
+	if s.optional && errors.Is(err, fs.ErrNotExist) {
+		return &nested.Nested{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch extension := strings.ToLower(filepath.Ext(s.path)); extension {
+	case ".json":
+		return nested.ParseJSONString(string(data), nested.ParseOptions{})
+	case ".yaml", ".yml":
+		return nested.FromYAML(string(data))
+	case ".toml":
+		return nested.FromTOML(string(data))
+	default:
+		return nil, fmt.Errorf("unsupported file extension '%s'", extension)
+	}
+}
+
+// Источник из переменных окружения.
+type envSource struct {
+	prefix    string
+	separator string
+}
+
+// Источник из переменных окружения с префиксом prefix, см. [nested.Nested.LoadEnv].
+//
+// Ключи сопоставляются с ключами, загруженными из предыдущих источников, без учета регистра,
+// поэтому, например, APP_DB__MAXCONNS переопределяет "maxConns" из файла. Именем источника является "env".
+func Env(prefix, separator string) Source {
+	return &envSource{prefix: prefix, separator: separator}
+}
+
+func (s *envSource) Name() string {
+	return "env"
+}
+
+func (s *envSource) Load() (*nested.Nested, error) {
+	result := &nested.Nested{}
+
+	if err := result.LoadEnv(s.prefix, s.separator); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Источник из флагов командной строки.
+type flagsSource struct {
+	flags *flag.FlagSet
+	paths map[string][]string
+}
+
+// Источник из явно переданных флагов командной строки, см. [nested.Nested.LoadFlags].
+//
+// Флаги должны быть разобраны до загрузки конфигурации, значения флагов без собственного хранения
+// (например, flag.Func) загружаются, только если до разбора был вызван [nested.RecordFlags].
+// Именем источника является "flags".
+func Flags(flags *flag.FlagSet, paths map[string][]string) Source {
+	return &flagsSource{flags: flags, paths: paths}
+}
+
+func (s *flagsSource) Name() string {
+	return "flags"
+}
+
+func (s *flagsSource) Load() (*nested.Nested, error) {
+	result := &nested.Nested{}
+
+	if err := result.LoadFlags(s.flags, s.paths); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Источник из готового объекта.
+type staticSource struct {
+	name  string
+	value *nested.Nested
+}
+
+// Источник из объекта в памяти, например, значений по умолчанию.
+//
+// Объект копируется при загрузке, поэтому его последующие изменения не влияют на загруженную конфигурацию.
+func Static(name string, value *nested.Nested) Source {
+	return &staticSource{name: name, value: value}
+}
+
+func (s *staticSource) Name() string {
+	return s.name
+}
+
+func (s *staticSource) Load() (*nested.Nested, error) {
+	return s.value.Clone(), nil
+}
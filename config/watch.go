@@ -0,0 +1,300 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	nested "github.com/NGRsoftlab/ngr-nested"
+)
+
+// Обработчик изменения поддерева конфигурации.
+//
+// old и new - копии поддерева до и после изменения, nil для отсутствующих ключей.
+type WatchHandler func(old, new *nested.Nested)
+
+// Подписка на изменения поддерева.
+type subscription struct {
+	keys    []string
+	handler WatchHandler
+}
+
+// Отслеживание изменений конфигурации с периодической перезагрузкой источников.
+//
+// Использует опрос, а не уведомления файловой системы, поэтому работает на любой платформе и с любыми
+// источниками, включая переменные окружения. При каждой проверке файлы, у которых не изменились время
+// изменения и размер, не перечитываются, а остальные источники загружаются заново. Конфигурация
+// объединяется заново, только если содержимое хотя бы одного источника изменилось, и сравнивается
+// с предыдущей версией (см. [nested.Diff]), а подписчики уведомляются только об изменениях в своих поддеревьях.
+//
+// Конкурентно-безопасен.
+//
+// Пример:
+//
+//	watcher, err := config.NewWatcher(time.Second, config.File("/etc/app/config.yaml"))
+//
+//	watcher.Subscribe(func(old, new *nested.Nested) {
+//		// обновление пула соединений
+//	}, "db")
+//
+//	watcher.Start()
+//	defer watcher.Stop()
+type Watcher struct {
+	sources  []Source
+	interval time.Duration
+
+	reloading sync.Mutex       // последовательное выполнение проверок
+	data      []*nested.Nested // объекты, загруженные из источников при последней проверке
+	stamps    []fileStamp      // состояние файловых источников при последней загрузке
+
+	mutex         sync.Mutex
+	config        *Config
+	subscriptions map[int]*subscription
+	nextID        int
+	errorHandler  func(error)
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Создание отслеживания с первоначальной загрузкой конфигурации.
+//
+// interval - период проверки изменений после вызова [Watcher.Start].
+// Возвращает ошибку первоначальной загрузки.
+func NewWatcher(interval time.Duration, sources ...Source) (*Watcher, error) {
+	w := &Watcher{
+		sources:       sources,
+		interval:      interval,
+		data:          make([]*nested.Nested, len(sources)),
+		stamps:        make([]fileStamp, len(sources)),
+		subscriptions: map[int]*subscription{},
+	}
+
+	data, stamps, _, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+
+	w.data, w.stamps = data, stamps
+	w.config = build(sources, data)
+
+	return w, nil
+}
+
+// Получение текущей версии конфигурации.
+func (w *Watcher) Config() *Config {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.config
+}
+
+// Подписка на изменения поддерева по цепочке ключей.
+//
+// Обработчик вызывается, если изменилось значение по цепочке ключей или любое вложенное в него.
+// Без ключей обработчик вызывается при любом изменении. Возвращает функцию отмены подписки.
+func (w *Watcher) Subscribe(handler WatchHandler, keys ...string) func() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	id := w.nextID
+	w.nextID++
+	w.subscriptions[id] = &subscription{keys: slices.Clone(keys), handler: handler}
+
+	return func() {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+
+		delete(w.subscriptions, id)
+	}
+}
+
+// Установка обработчика ошибок загрузки при фоновой проверке.
+//
+// При ошибке загрузки сохраняется предыдущая версия конфигурации.
+func (w *Watcher) OnError(handler func(error)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.errorHandler = handler
+}
+
+// Однократная проверка изменений.
+//
+// Загружает изменившиеся источники и, если их содержимое изменилось, уведомляет подписчиков об изменениях. Обработчики вызываются синхронно
+// в порядке подписки и не должны сами вызывать Reload. При ошибке загрузки текущая версия не меняется.
+func (w *Watcher) Reload() error {
+	w.reloading.Lock()
+	defer w.reloading.Unlock()
+
+	data, stamps, changed, err := w.load()
+	if err != nil {
+		return err
+	}
+
+	w.data, w.stamps = data, stamps
+
+	if !changed {
+		return nil
+	}
+
+	config := build(w.sources, data)
+
+	w.mutex.Lock()
+
+	previous := w.config
+	w.config = config
+
+	changes := nested.Diff(previous.data, config.data)
+
+	ids := make([]int, 0, len(w.subscriptions))
+	for id := range w.subscriptions {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	notify := []*subscription{}
+	for _, id := range ids {
+		if affected(w.subscriptions[id].keys, changes) {
+			notify = append(notify, w.subscriptions[id])
+		}
+	}
+
+	w.mutex.Unlock()
+
+	// обработчики вызываются без блокировки, чтобы они могли обращаться к отслеживанию
+	for _, s := range notify {
+		s.handler(subtree(previous, s.keys), subtree(config, s.keys))
+	}
+
+	return nil
+}
+
+// Загрузка источников, изменившихся с последней проверки.
+//
+// Файлы с прежними временем изменения и размером не перечитываются. Возвращает объекты и состояния
+// файлов для всех источников и признак, что содержимое хотя бы одного источника изменилось.
+func (w *Watcher) load() ([]*nested.Nested, []fileStamp, bool, error) {
+	data := slices.Clone(w.data)
+	stamps := slices.Clone(w.stamps)
+	changed := false
+
+	for index, source := range w.sources {
+		if file, ok := source.(*fileSource); ok {
+			// состояние получается до чтения, чтобы изменение во время чтения обнаружилось при следующей проверке
+			stamp, err := file.stamp()
+			if err != nil {
+				return nil, nil, false, fmt.Errorf("%s: %w", source.Name(), err)
+			}
+
+			if stamp == stamps[index] {
+				continue
+			}
+
+			stamps[index] = stamp
+		}
+
+		loaded, err := loadSource(source)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		if !equalData(loaded, data[index]) {
+			changed = true
+		}
+
+		data[index] = loaded
+	}
+
+	return data, stamps, changed, nil
+}
+
+// Запуск периодической проверки изменений в отдельной горутине.
+//
+// Повторный вызов без [Watcher.Stop] ничего не делает.
+func (w *Watcher) Start() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.stop != nil {
+		return
+	}
+
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+
+	go w.run(w.stop, w.done)
+}
+
+// Остановка периодической проверки с ожиданием завершения текущей проверки.
+func (w *Watcher) Stop() {
+	w.mutex.Lock()
+	stop, done := w.stop, w.done
+	w.stop, w.done = nil, nil
+	w.mutex.Unlock()
+
+	if stop == nil {
+		return
+	}
+
+	close(stop)
+	<-done
+}
+
+// Цикл периодической проверки.
+func (w *Watcher) run(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := w.Reload(); err != nil {
+				w.mutex.Lock()
+				handler := w.errorHandler
+				w.mutex.Unlock()
+
+				if handler != nil {
+					handler(err)
+				}
+			}
+		}
+	}
+}
+
+// Проверка, затрагивает ли одно из изменений поддерево по цепочке ключей.
+func affected(keys []string, changes []nested.Change) bool {
+	for _, change := range changes {
+		length := min(len(keys), len(change.Path))
+		if slices.Equal(keys[:length], change.Path[:length]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Сравнение объектов, загруженных из источника, с учетом пустых источников.
+func equalData(a, b *nested.Nested) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return nested.Equals(a, b)
+}
+
+// Копия поддерева конфигурации или nil, если его нет.
+func subtree(config *Config, keys []string) *nested.Nested {
+	value, err := config.Get(keys...)
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	nested "github.com/NGRsoftlab/ngr-nested"
+)
+
+func Test_Watcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"db": {"host": "a", "port": 1}, "log": "info"}`), 0o600))
+
+	watcher, err := NewWatcher(time.Hour, Static("defaults", nested.FromJSONString(`{"cache": {"size": 10}}`)), File(path))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	type event struct {
+		name     string
+		old, new any
+	}
+
+	// объекты сравниваются без учета порядка добавления ключей
+	object := func(j *nested.Nested) any {
+		if j == nil {
+			return nil
+		}
+
+		return j.ToObject()
+	}
+
+	events := []event{}
+	subscribe := func(name string, keys ...string) func() {
+		return watcher.Subscribe(func(old, new *nested.Nested) {
+			events = append(events, event{name, object(old), object(new)})
+		}, keys...)
+	}
+
+	subscribe("db", "db")
+	subscribe("host", "db", "host")
+	subscribe("cache", "cache")
+	unsubscribe := subscribe("log", "log")
+	subscribe("all")
+
+	// без изменений подписчики не уведомляются
+	assert.Nil(t, watcher.Reload())
+	assert.Empty(t, events)
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"db": {"host": "a", "port": 2}, "log": "debug", "new": true}`), 0o600))
+	unsubscribe()
+
+	assert.Nil(t, watcher.Reload())
+	assert.Equal(t, []event{
+		{"db", object(nested.FromJSONString(`{"host": "a", "port": 1}`)), object(nested.FromJSONString(`{"host": "a", "port": 2}`))},
+		{"all", object(nested.FromJSONString(`{"cache": {"size": 10}, "db": {"host": "a", "port": 1}, "log": "info"}`)),
+			object(nested.FromJSONString(`{"cache": {"size": 10}, "db": {"host": "a", "port": 2}, "log": "debug", "new": true}`))},
+	}, events)
+
+	value, err := watcher.Config().GetValue("db", "port")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, value)
+	}
+
+	events = events[:0]
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"log": "debug"}`), 0o600))
+	assert.Nil(t, watcher.Reload())
+
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, event{"db", object(nested.FromJSONString(`{"host": "a", "port": 2}`)), nil}, events[0])
+		assert.Equal(t, event{"host", "a", nil}, events[1])
+		assert.Equal(t, "all", events[2].name)
+	}
+
+	// при ошибке загрузки сохраняется предыдущая версия
+	assert.Nil(t, os.WriteFile(path, []byte(`{"log": `), 0o600))
+	assert.EqualError(t, watcher.Reload(), path+": unexpected EOF")
+
+	value, err = watcher.Config().GetValue("log")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "debug", value)
+	}
+
+	_, err = NewWatcher(time.Hour, File(filepath.Join(t.TempDir(), "missing.json")))
+	assert.NotNil(t, err)
+}
+
+func Test_WatcherUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"a": 1}`), 0o600))
+
+	watcher, err := NewWatcher(time.Hour, File(path), OptionalFile(filepath.Join(t.TempDir(), "missing.json")))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	events := 0
+	watcher.Subscribe(func(old, new *nested.Nested) {
+		events++
+	})
+
+	config := watcher.Config()
+
+	info, err := os.Stat(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// файл с прежними временем изменения и размером не перечитывается
+	assert.Nil(t, os.WriteFile(path, []byte(`{"a": }}`), 0o600))
+	assert.Nil(t, os.Chtimes(path, info.ModTime(), info.ModTime()))
+	assert.Nil(t, watcher.Reload())
+	assert.Same(t, config, watcher.Config())
+
+	// перечитанный файл с прежним содержимым не меняет конфигурацию
+	assert.Nil(t, os.WriteFile(path, []byte(`{"a": 1}`), 0o600))
+	assert.Nil(t, os.Chtimes(path, info.ModTime().Add(time.Second), info.ModTime().Add(time.Second)))
+	assert.Nil(t, watcher.Reload())
+	assert.Same(t, config, watcher.Config())
+	assert.Equal(t, 0, events)
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"a": 2}`), 0o600))
+	assert.Nil(t, os.Chtimes(path, info.ModTime().Add(2*time.Second), info.ModTime().Add(2*time.Second)))
+	assert.Nil(t, watcher.Reload())
+	assert.NotSame(t, config, watcher.Config())
+	assert.Equal(t, 1, events)
+}
+
+func Test_WatcherStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"a": 1}`), 0o600))
+
+	watcher, err := NewWatcher(time.Millisecond, File(path))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	changed := make(chan *nested.Nested, 1)
+	watcher.Subscribe(func(old, new *nested.Nested) {
+		select {
+		case changed <- new:
+		default:
+		}
+	}, "a")
+
+	failed := make(chan error, 1)
+	watcher.OnError(func(err error) {
+		select {
+		case failed <- err:
+		default:
+		}
+	})
+
+	watcher.Start()
+	watcher.Start()
+	defer watcher.Stop()
+
+	assert.Nil(t, os.WriteFile(path, []byte(`{"a": 2}`), 0o600))
+
+	select {
+	case value := <-changed:
+		assert.Equal(t, nested.FromJSONString(`2`), value)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change was not detected")
+	}
+
+	assert.Nil(t, os.Remove(path))
+
+	select {
+	case err := <-failed:
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	case <-time.After(5 * time.Second):
+		t.Fatal("error was not reported")
+	}
+
+	watcher.Stop()
+	watcher.Stop()
+}
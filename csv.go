@@ -0,0 +1,268 @@
+package nested
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Разделитель вложенных ключей в именах колонок CSV.
+const csvKeySeparator = "."
+
+// Запись массива объектов по цепочке ключей в CSV.
+//
+// Каждый элемент массива должен быть объектом ключ-значение и становится строкой таблицы.
+// Колонки - объединение ключей всех элементов в алфавитном порядке, вложенные объекты разворачиваются
+// в колонки вида "a.b". Отсутствующие ключи и значения nil записываются пустыми ячейками,
+// вложенные массивы - JSON-строкой, двоичные данные ([]byte) - в base64, время (time.Time) - в формате RFC 3339.
+//
+// Возвращает ошибку, если ключ содержит точку, вложенный объект пуст или одна колонка является префиксом другой
+// (например, "a" в одном элементе и "a.b" в другом), так как такую таблицу нельзя однозначно разобрать обратно.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"users": [{"name": "Alice", "address": {"city": "Paris"}}, {"name": "Bob", "age": 30}]}`)
+//
+//	nested.ToCSV(os.Stdout, "users")
+//
+//	// address.city,age,name
+//	// Paris,,Alice
+//	// ,30,Bob
+func (j *Nested) ToCSV(w io.Writer, keys ...string) error {
+	array, err := j.GetArray(keys...)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]map[string]string, 0, len(array))
+	columns := map[string]bool{}
+
+	for index, element := range array {
+		path := append(slices.Clone(keys), strconv.Itoa(index))
+
+		if element == nil {
+			rows = append(rows, map[string]string{})
+			continue
+		}
+
+		if element.IsValue() {
+			return pathError(path, "is value")
+		}
+
+		if element.IsArray() {
+			return pathError(path, "is array")
+		}
+
+		row := map[string]string{}
+		if err := flattenCSV(element, "", path, row); err != nil {
+			return err
+		}
+
+		for column := range row {
+			columns[column] = true
+		}
+
+		rows = append(rows, row)
+	}
+
+	if err := checkCSVColumns(columns); err != nil {
+		return err
+	}
+
+	header := make([]string, 0, len(columns))
+	for column := range columns {
+		header = append(header, column)
+	}
+
+	slices.Sort(header)
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, len(header))
+		for index, column := range header {
+			record[index] = row[column]
+		}
+
+		// csv.Writer записывает единственную пустую ячейку пустой строкой, которую csv.Reader пропускает
+		if len(record) == 1 && record[0] == "" {
+			writer.Flush()
+
+			if err := writer.Error(); err != nil {
+				return err
+			}
+
+			if _, err := io.WriteString(w, "\"\"\n"); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// Рекурсивное разворачивание объекта в ячейки строки CSV.
+//
+// prefix - имя колонки объекта, path - цепочка ключей до объекта для сообщений об ошибках.
+func flattenCSV(j *Nested, prefix string, path []string, row map[string]string) error {
+	if j == nil {
+		row[prefix] = ""
+		return nil
+	}
+
+	if j.IsNested() {
+		// пустой вложенный объект не дает ни одной колонки и потерялся бы при обратном разборе
+		if len(j.nested) == 0 && prefix != "" {
+			return pathError(path, "empty object has no columns")
+		}
+
+		for k, element := range j.nested {
+			if strings.Contains(k, csvKeySeparator) {
+				return pathError(path, "key '%s' contains '%s'", k, csvKeySeparator)
+			}
+
+			column := k
+			if prefix != "" {
+				column = prefix + csvKeySeparator + k
+			}
+
+			if err := flattenCSV(element, column, append(slices.Clone(path), k), row); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if j.IsArray() {
+		row[prefix] = j.ToJSONString()
+		return nil
+	}
+
+	switch value := j.value.(type) {
+	case nil:
+		row[prefix] = ""
+	case string:
+		row[prefix] = value
+	case []byte:
+		row[prefix] = base64.StdEncoding.EncodeToString(value)
+	case time.Time:
+		row[prefix] = value.Format(time.RFC3339Nano)
+	default:
+		row[prefix] = fmt.Sprint(value)
+	}
+
+	return nil
+}
+
+// Создание массива объектов из CSV.
+//
+// Первая строка - заголовок с именами колонок, каждая следующая строка становится объектом ключ-значение.
+// Колонки вида "a.b" становятся вложенными объектами. Пустые ячейки пропускаются.
+//
+// При inferTypes равном true значения ячеек конвертируются в int, float64 или bool, если имеют вид
+// JSON-числа, true или false. Иначе все значения сохраняются строками.
+//
+// Пример:
+//
+//	nested, err := FromCSV(strings.NewReader("name,address.city,age\nAlice,Paris,30\n"), true)
+//
+//	nested.ToJSONString() // [{"address": {"city": "Paris"}, "age": 30, "name": "Alice"}]
+func FromCSV(r io.Reader, inferTypes bool) (*Nested, error) {
+	reader := csv.NewReader(r)
+
+	result := &Nested{isArray: true, array: []*Nested{}}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([][]string, 0, len(header))
+	seen := map[string]bool{}
+
+	for _, column := range header {
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate column '%s'", column)
+		}
+
+		seen[column] = true
+
+		keys := strings.Split(column, csvKeySeparator)
+		if slices.Contains(keys, "") {
+			return nil, fmt.Errorf("invalid column name '%s'", column)
+		}
+
+		columns = append(columns, keys)
+	}
+
+	if err := checkCSVColumns(seen); err != nil {
+		return nil, err
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		element := &Nested{nested: map[string]*Nested{}}
+
+		for index, cell := range record {
+			if cell == "" {
+				continue
+			}
+
+			var value any = cell
+			if inferTypes {
+				value = inferValue(cell)
+			}
+
+			if err := element.SetValue(value, columns[index]...); err != nil {
+				return nil, fmt.Errorf("line %d: %s", line, err.Error())
+			}
+		}
+
+		result.array = append(result.array, element)
+	}
+}
+
+// Проверка, что ни одна колонка не является вложенным объектом для другой колонки.
+func checkCSVColumns(columns map[string]bool) error {
+	for column := range columns {
+		for index := range column {
+			if strings.HasPrefix(column[index:], csvKeySeparator) && columns[column[:index]] {
+				return fmt.Errorf("column '%s' conflicts with column '%s'", column[:index], column)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package nested
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ToCSV(t *testing.T) {
+	nested := FromJSONString(`{"report": {"users": [
+		{"name": "Alice", "address": {"city": "Paris", "zip": "75001"}, "tags": ["a", "b"]},
+		{"name": "Bob, Jr.", "age": 30, "active": true, "score": 1.5, "note": null},
+		{}
+	]}}`)
+	nested.ArrayAdd(nil, "report", "users")
+	nested.ArrayAdd(&Nested{nested: map[string]*Nested{
+		"name": {isValue: true, value: "Carol"},
+		"at":   {isValue: true, value: time.Date(2024, 3, 18, 10, 0, 0, 0, time.UTC)},
+	}}, "report", "users")
+
+	buffer := &bytes.Buffer{}
+
+	if assert.Nil(t, nested.ToCSV(buffer, "report", "users")) {
+		assert.Equal(t, "active,address.city,address.zip,age,at,name,note,score,tags\n"+
+			",Paris,75001,,,Alice,,,\"[\"\"a\"\",\"\"b\"\"]\"\n"+
+			"true,,,30,,\"Bob, Jr.\",,1.5,\n"+
+			",,,,,,,,\n"+
+			",,,,,,,,\n"+
+			",,,,2024-03-18T10:00:00Z,Carol,,,\n", buffer.String())
+	}
+
+	buffer.Reset()
+
+	// пустой элемент массива становится строкой из пустых ячеек
+	buffer.Reset()
+	if assert.Nil(t, FromJSONString(`[{"a": 1}, {}]`).ToCSV(buffer)) {
+		assert.Equal(t, "a\n1\n\"\"\n", buffer.String())
+
+		nested, err := FromCSV(buffer, true)
+		if assert.Nil(t, err) {
+			assert.Equal(t, `[{"a":1},{}]`, nested.ToJSONString())
+		}
+	}
+
+	buffer.Reset()
+	if assert.Nil(t, FromJSONString(`[]`).ToCSV(buffer)) {
+		assert.Equal(t, "\n", buffer.String())
+	}
+
+	cases := []struct {
+		nested *Nested
+		keys   []string
+		err    string
+	}{
+		{FromJSONString(`{"a": [1]}`), []string{"a"}, "a.0: is value"},
+		{FromJSONString(`{"a": [[1]]}`), []string{"a"}, "a.0: is array"},
+		{FromJSONString(`[{"a": {"b.c": 1}}]`), nil, "0.a: key 'b.c' contains '.'"},
+		{FromJSONString(`[{"a": 1}, {"a": {"b": 2}}]`), nil, "column 'a' conflicts with column 'a.b'"},
+		{FromJSONString(`[{"a": 1, "b": {"c": {}}}]`), nil, "0.b.c: empty object has no columns"},
+		{FromJSONString(`{"a": {}}`), []string{"a"}, "a: is nested"},
+		{FromJSONString(`{"a": {}}`), nil, "is nested"},
+	}
+
+	for _, c := range cases {
+		assert.EqualError(t, c.nested.ToCSV(&bytes.Buffer{}, c.keys...), c.err)
+	}
+}
+
+func Test_FromCSV(t *testing.T) {
+	data := "name,address.city,age,active,score,id\n" +
+		"Alice,Paris,30,true,1.5,007\n" +
+		"\"Bob, Jr.\",,,False,-2e3,0x10\n"
+
+	nested, err := FromCSV(strings.NewReader(data), true)
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{isArray: true, array: []*Nested{
+			{nested: map[string]*Nested{
+				"name":    {isValue: true, value: "Alice"},
+				"address": {nested: map[string]*Nested{"city": {isValue: true, value: "Paris"}}, order: []string{"city"}},
+				"age":     {isValue: true, value: 30},
+				"active":  {isValue: true, value: true},
+				"score":   {isValue: true, value: 1.5},
+				"id":      {isValue: true, value: "007"},
+			}, order: []string{"name", "address", "age", "active", "score", "id"}},
+			{nested: map[string]*Nested{
+				"name":   {isValue: true, value: "Bob, Jr."},
+				"active": {isValue: true, value: "False"},
+				"score":  {isValue: true, value: -2000.0},
+				"id":     {isValue: true, value: "0x10"},
+			}, order: []string{"name", "active", "score", "id"}},
+		}}, nested)
+	}
+
+	nested, err = FromCSV(strings.NewReader(data), false)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "30", nested.array[0].nested["age"].value)
+	}
+
+	// разбор результата ToCSV восстанавливает исходный массив
+	original := FromJSONString(`[{"a": {"b": 1, "c": "x"}, "d": true}, {"d": false, "e": 2.5}]`)
+	buffer := &bytes.Buffer{}
+
+	if assert.Nil(t, original.ToCSV(buffer)) {
+		nested, err := FromCSV(buffer, true)
+		if assert.Nil(t, err) {
+			assert.Equal(t, original.ToObject(), nested.ToObject())
+		}
+	}
+
+	nested, err = FromCSV(strings.NewReader(""), true)
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{isArray: true, array: []*Nested{}}, nested)
+	}
+
+	cases := []struct {
+		data string
+		err  string
+	}{
+		{"a,a\n", "duplicate column 'a'"},
+		{"a,.b\n", "invalid column name '.b'"},
+		{"a.b,a\n", "column 'a' conflicts with column 'a.b'"},
+		{"a,b\n1\n", "record on line 2: wrong number of fields"},
+		{"a\n\"x\n", "parse error on line 2, column 4: extraneous or missing \" in quoted-field"},
+	}
+
+	for _, c := range cases {
+		_, err := FromCSV(strings.NewReader(c.data), true)
+		assert.EqualError(t, err, c.err, c.data)
+	}
+}
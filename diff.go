@@ -0,0 +1,99 @@
+package nested
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// Изменение между двумя версиями объекта.
+type Change struct {
+	Path []string // цепочка ключей до измененного объекта, для элементов массива - индекс в виде строки
+	Old  *Nested  // объект в исходной версии, nil для добавленных ключей и элементов
+	New  *Nested  // объект в новой версии, nil для удаленных ключей и элементов
+}
+
+// Вычисление изменений между двумя версиями объекта.
+//
+// Изменения возвращаются на минимальной глубине: для объектов ключ-значение сравниваются ключи,
+// для массивов - элементы с одинаковыми индексами, для значений - сами значения через reflect.DeepEqual.
+// Если объект изменил вид (например, значение стало массивом), изменение возвращается для него целиком.
+//
+// Изменения упорядочены по ключам в алфавитном порядке и по индексам. Old и New указывают на объекты
+// из переданных версий, а не на копии.
+//
+// Пример:
+//
+//	a := FromJSONString(`{"a": 1, "b": [1, 2], "c": true}`)
+//	b := FromJSONString(`{"a": 2, "b": [1], "d": "x"}`)
+//
+//	Diff(a, b) // пути изменений: [a], [b 1], [c], [d]
+func Diff(a, b *Nested) []Change {
+	changes := []Change{}
+	diff(a, b, []string{}, &changes)
+
+	return changes
+}
+
+// Рекурсивное сравнение объектов.
+func diff(a, b *Nested, path []string, changes *[]Change) {
+	if a == nil || b == nil {
+		if a != b {
+			*changes = append(*changes, Change{Path: path, Old: a, New: b})
+		}
+
+		return
+	}
+
+	switch {
+	case a.IsValue() && b.IsValue():
+		if !reflect.DeepEqual(a.value, b.value) {
+			*changes = append(*changes, Change{Path: path, Old: a, New: b})
+		}
+	case a.IsArray() && b.IsArray():
+		for index := 0; index < max(len(a.array), len(b.array)); index++ {
+			var before, after *Nested
+
+			if index < len(a.array) {
+				before = a.array[index]
+			}
+
+			if index < len(b.array) {
+				after = b.array[index]
+			}
+
+			elementPath := append(slices.Clone(path), strconv.Itoa(index))
+
+			if index >= len(a.array) || index >= len(b.array) {
+				*changes = append(*changes, Change{Path: elementPath, Old: before, New: after})
+				continue
+			}
+
+			diff(before, after, elementPath, changes)
+		}
+	case a.IsNested() && b.IsNested():
+		keys := sortedKeys(a.nested)
+		for k := range b.nested {
+			if _, ok := a.nested[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			before, beforeOk := a.nested[k]
+			after, afterOk := b.nested[k]
+			keyPath := append(slices.Clone(path), k)
+
+			if !beforeOk || !afterOk {
+				*changes = append(*changes, Change{Path: keyPath, Old: before, New: after})
+				continue
+			}
+
+			diff(before, after, keyPath, changes)
+		}
+	default:
+		*changes = append(*changes, Change{Path: path, Old: a, New: b})
+	}
+}
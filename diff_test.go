@@ -0,0 +1,35 @@
+package nested
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Diff(t *testing.T) {
+	a := FromJSONString(`{"a": 1, "b": [1, 2, {"x": 1}], "c": true, "e": {"f": "g"}, "h": [1], "same": {"k": [1, "v"]}}`)
+	b := FromJSONString(`{"a": 2, "b": [1, 3, {"x": 2}, 4], "d": "x", "e": [1], "h": [], "same": {"k": [1, "v"]}}`)
+
+	changes := Diff(a, b)
+
+	paths := [][]string{}
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+
+	assert.Equal(t, [][]string{{"a"}, {"b", "1"}, {"b", "2", "x"}, {"b", "3"}, {"c"}, {"d"}, {"e"}, {"h", "0"}}, paths)
+
+	assert.Equal(t, Change{Path: []string{"a"}, Old: a.nested["a"], New: b.nested["a"]}, changes[0])
+	assert.Equal(t, Change{Path: []string{"b", "3"}, Old: nil, New: b.nested["b"].array[3]}, changes[3])
+	assert.Equal(t, Change{Path: []string{"c"}, Old: a.nested["c"], New: nil}, changes[4])
+	assert.Equal(t, Change{Path: []string{"e"}, Old: a.nested["e"], New: b.nested["e"]}, changes[6])
+	assert.Equal(t, Change{Path: []string{"h", "0"}, Old: a.nested["h"].array[0], New: nil}, changes[7])
+
+	assert.Equal(t, []Change{}, Diff(a, a))
+	assert.Equal(t, []Change{}, Diff(nil, nil))
+	assert.Equal(t, []Change{{Path: []string{}, Old: nil, New: b}}, Diff(nil, b))
+
+	value := &Nested{isValue: true, value: []byte{1}}
+	assert.Equal(t, []Change{}, Diff(value, &Nested{isValue: true, value: []byte{1}}))
+	assert.Len(t, Diff(value, &Nested{isValue: true, value: 1}), 1)
+}
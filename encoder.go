@@ -0,0 +1,266 @@
+package nested
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Потоковый кодировщик объектов Nested в JSON.
+//
+// В отличие от [Nested.ToJSONString], записывает результат напрямую в io.Writer без построения
+// всей строки в памяти и возвращает ошибки конвертации и записи.
+//
+// Настройки по умолчанию совпадают с поведением [Nested.ToJSONString]: компактный вывод,
+// ключи словарей отсортированы, экранирование HTML-символов отключено.
+// Строковые значения верхнего уровня, в отличие от [Nested.ToJSONString], выводятся в кавычках,
+// чтобы результат всегда был корректным JSON.
+//
+// Пример:
+//
+//	encoder := NewEncoder(os.Stdout)
+//	encoder.SetIndent("", "  ")
+//	encoder.Encode(FromJSONString(`{"b": 1, "a": [1, 2]}`))
+//
+//	// {
+//	//   "a": [
+//	//     1,
+//	//     2
+//	//   ],
+//	//   "b": 1
+//	// }
+type Encoder struct {
+	w io.Writer
+
+	prefix string // префикс каждой строки, кроме первой
+	indent string // отступ одного уровня вложенности
+
+	sortKeys   bool // сортировка ключей словарей
+	escapeHTML bool // экранирование символов <, >, &
+}
+
+// Создание кодировщика, записывающего результат в w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:        w,
+		sortKeys: true,
+	}
+}
+
+// Установка отступов для форматированного вывода.
+//
+// Каждый элемент массива или словаря начинается с новой строки с префиксом prefix,
+// за которым indent повторяется по уровню вложенности.
+// Если оба аргумента пустые, вывод будет компактным.
+func (e *Encoder) SetIndent(prefix, indent string) {
+	e.prefix = prefix
+	e.indent = indent
+}
+
+// Включение или отключение сортировки ключей словарей.
+//
+// Без сортировки ключи выводятся в порядке добавления через [Nested.Set] и производные от него функции.
+// Порядок ключей, полученных иначе (например, через [FromJSONString], [FromObject] или [Nested.SetMap]),
+// не сохраняется: такие ключи выводятся после остальных в отсортированном порядке.
+func (e *Encoder) SetSortKeys(on bool) {
+	e.sortKeys = on
+}
+
+// Включение или отключение экранирования символов <, > и & в строках.
+func (e *Encoder) SetEscapeHTML(on bool) {
+	e.escapeHTML = on
+}
+
+// Запись объекта в JSON с переводом строки в конце.
+//
+// Возвращает ошибку, если одно из скалярных значений не может быть сконвертировано в JSON
+// (например, канал или NaN), или если произошла ошибка записи.
+// При ошибке конвертации в w может быть записана часть объекта.
+func (e *Encoder) Encode(j *Nested) error {
+	// буферизованные writer'ы (bytes.Buffer, bufio.Writer) используются напрямую
+	if writer, ok := e.w.(encodeWriter); ok {
+		if err := e.encode(writer, j, 0); err != nil {
+			return err
+		}
+
+		return writer.WriteByte('\n')
+	}
+
+	writer := bufio.NewWriter(e.w)
+
+	if err := e.encode(writer, j, 0); err != nil {
+		return err
+	}
+
+	if err := writer.WriteByte('\n'); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// Интерфейс writer'а, в который кодировщик пишет результат.
+type encodeWriter interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+}
+
+// Рекурсивная запись объекта с учетом уровня вложенности.
+func (e *Encoder) encode(w encodeWriter, j *Nested, depth int) error {
+	if j == nil {
+		_, err := w.WriteString("null")
+		return err
+	}
+
+	if j.IsValue() {
+		return e.encodeValue(w, j.value, depth)
+	}
+
+	if j.IsArray() {
+		if len(j.array) == 0 {
+			_, err := w.WriteString("[]")
+			return err
+		}
+
+		if err := w.WriteByte('['); err != nil {
+			return err
+		}
+
+		for index, element := range j.array {
+			if err := e.separator(w, index, depth+1); err != nil {
+				return err
+			}
+
+			if err := e.encode(w, element, depth+1); err != nil {
+				return err
+			}
+		}
+
+		if err := e.newline(w, depth); err != nil {
+			return err
+		}
+
+		return w.WriteByte(']')
+	}
+
+	if len(j.nested) == 0 {
+		_, err := w.WriteString("{}")
+		return err
+	}
+
+	if err := w.WriteByte('{'); err != nil {
+		return err
+	}
+
+	keys := j.orderedKeys()
+	if e.sortKeys {
+		keys = sortedKeys(j.nested)
+	}
+
+	for index, k := range keys {
+		if err := e.separator(w, index, depth+1); err != nil {
+			return err
+		}
+
+		if err := e.encodeValue(w, k, depth+1); err != nil {
+			return err
+		}
+
+		colon := ":"
+		if e.indent != "" || e.prefix != "" {
+			colon = ": "
+		}
+
+		if _, err := w.WriteString(colon); err != nil {
+			return err
+		}
+
+		if err := e.encode(w, j.nested[k], depth+1); err != nil {
+			return err
+		}
+	}
+
+	if err := e.newline(w, depth); err != nil {
+		return err
+	}
+
+	return w.WriteByte('}')
+}
+
+// Запись скалярного значения через пакет encoding/json.
+//
+// Значение может само быть составным (например, map[string]int),
+// поэтому отступы передаются кодировщику с учетом текущей вложенности.
+func (e *Encoder) encodeValue(w encodeWriter, value any, depth int) error {
+	buffer := &bytes.Buffer{}
+
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(e.escapeHTML)
+	if e.indent != "" || e.prefix != "" {
+		encoder.SetIndent(e.prefix+strings.Repeat(e.indent, depth), e.indent)
+	}
+
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+
+	// json.Encoder всегда добавляет перевод строки в конце
+	_, err := w.Write(bytes.TrimSuffix(buffer.Bytes(), []byte{'\n'}))
+
+	return err
+}
+
+// Запятая перед элементом (кроме первого) и перевод строки с отступом.
+func (e *Encoder) separator(w encodeWriter, index, depth int) error {
+	if index > 0 {
+		if err := w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+
+	return e.newline(w, depth)
+}
+
+// Перевод строки с отступом для заданного уровня вложенности.
+func (e *Encoder) newline(w encodeWriter, depth int) error {
+	if e.indent == "" && e.prefix == "" {
+		return nil
+	}
+
+	_, err := w.WriteString("\n" + e.prefix + strings.Repeat(e.indent, depth))
+
+	return err
+}
+
+// Конвертация объекта в форматированный JSON с возвратом ошибки.
+//
+// Аналог [json.MarshalIndent] для Nested: ключи словарей сортируются,
+// экранирование HTML-символов отключено, перевод строки в конце не добавляется.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"a": [1, 2]}`)
+//
+//	data, err := nested.MarshalJSONIndent("", "  ")
+//
+//	// {
+//	//   "a": [
+//	//     1,
+//	//     2
+//	//   ]
+//	// }
+func (j *Nested) MarshalJSONIndent(prefix, indent string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+
+	encoder := NewEncoder(buffer)
+	encoder.SetIndent(prefix, indent)
+
+	if err := encoder.Encode(j); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte{'\n'}), nil
+}
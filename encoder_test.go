@@ -0,0 +1,166 @@
+package nested
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Writer, всегда возвращающий ошибку.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func Test_Encoder(t *testing.T) {
+	nested := testNested()
+
+	buffer := &bytes.Buffer{}
+	err := NewEncoder(buffer).Encode(&nested)
+	if assert.Nil(t, err) {
+		assert.Equal(t, nested.ToJSONString()+"\n", buffer.String())
+	}
+
+	buffer.Reset()
+	err = NewEncoder(buffer).Encode(&Nested{isValue: true, value: "string"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "\"string\"\n", buffer.String())
+	}
+
+	buffer.Reset()
+	err = NewEncoder(buffer).Encode(FromJSONString(`{"a": [], "b": {}, "c": null}`))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "{\"a\":[],\"b\":{},\"c\":null}\n", buffer.String())
+	}
+
+	buffer.Reset()
+	encoder := NewEncoder(buffer)
+	encoder.SetIndent(">", "  ")
+	err = encoder.Encode(FromJSONString(`{"b": 1, "a": [1, {"c": "d"}]}`))
+	if assert.Nil(t, err) {
+		assert.Equal(t,
+			"{\n>  \"a\": [\n>    1,\n>    {\n>      \"c\": \"d\"\n>    }\n>  ],\n>  \"b\": 1\n>}\n",
+			buffer.String(),
+		)
+	}
+
+	buffer.Reset()
+	encoder = NewEncoder(buffer)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(&Nested{nested: map[string]*Nested{
+		"value": {isValue: true, value: map[string]int{"a": 1}},
+	}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "{\n  \"value\": {\n    \"a\": 1\n  }\n}\n", buffer.String())
+	}
+
+	buffer.Reset()
+	err = NewEncoder(buffer).Encode(&Nested{isValue: true, value: "<a&b>"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "\"<a&b>\"\n", buffer.String())
+	}
+
+	buffer.Reset()
+	encoder = NewEncoder(buffer)
+	encoder.SetEscapeHTML(true)
+	err = encoder.Encode(&Nested{isValue: true, value: "<a&b>"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "\"\\u003ca\\u0026b\\u003e\"\n", buffer.String())
+	}
+
+	// без сортировки ключи выводятся в порядке добавления, ключи с неизвестным порядком - после них
+	ordered := FromJSONString(`{"m": 1, "a": 2}`)
+	assert.Nil(t, ordered.SetValue(3, "z"))
+	assert.Nil(t, ordered.SetValue(4, "b", "y"))
+	assert.Nil(t, ordered.SetValue(5, "b", "x"))
+	assert.Nil(t, ordered.SetValue(6, "c"))
+	assert.Nil(t, ordered.Delete("z"))
+	assert.Nil(t, ordered.SetValue(7, "z"))
+	assert.Nil(t, ordered.SetValue(8, "c"))
+
+	buffer.Reset()
+	encoder = NewEncoder(buffer)
+	encoder.SetSortKeys(false)
+	err = encoder.Encode(ordered.Clone())
+	if assert.Nil(t, err) {
+		assert.Equal(t, "{\"b\":{\"y\":4,\"x\":5},\"c\":8,\"z\":7,\"a\":2,\"m\":1}\n", buffer.String())
+	}
+
+	buffer.Reset()
+	err = NewEncoder(buffer).Encode(ordered)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "{\"a\":2,\"b\":{\"x\":5,\"y\":4},\"c\":8,\"m\":1,\"z\":7}\n", buffer.String())
+	}
+
+	err = NewEncoder(&bytes.Buffer{}).Encode(&Nested{isValue: true, value: math.NaN()})
+	assert.EqualError(t, err, "json: unsupported value: NaN")
+
+	err = NewEncoder(&bytes.Buffer{}).Encode(&Nested{isValue: true, value: make(chan int)})
+	assert.EqualError(t, err, "json: unsupported type: chan int")
+
+	err = NewEncoder(failingWriter{}).Encode(&nested)
+	assert.EqualError(t, err, "write failed")
+
+	// ошибка записи любого байта возвращается
+	encoded := FromJSONString(`{"a": [1, {"b": 2}], "c": {}}`)
+	for _, indent := range []string{"", "  "} {
+		full := &limitedWriter{limit: 1 << 10}
+		encoder = NewEncoder(full)
+		encoder.SetIndent("", indent)
+
+		if assert.Nil(t, encoder.Encode(encoded)) {
+			for limit := 0; limit < full.Len(); limit++ {
+				encoder = NewEncoder(&limitedWriter{limit: limit})
+				encoder.SetIndent("", indent)
+
+				assert.EqualError(t, encoder.Encode(encoded), "write failed", "limit %d", limit)
+			}
+		}
+	}
+}
+
+// Буферизованный writer, возвращающий ошибку после записи limit байт.
+type limitedWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.limit {
+		return 0, errors.New("write failed")
+	}
+
+	return w.Buffer.Write(p)
+}
+
+func (w *limitedWriter) WriteByte(c byte) error {
+	_, err := w.Write([]byte{c})
+	return err
+}
+
+func (w *limitedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func Test_MarshalJSONIndent(t *testing.T) {
+	nested := FromJSONString(`{"a": [1, 2], "b": "<c>"}`)
+
+	data, err := nested.MarshalJSONIndent("", "\t")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "{\n\t\"a\": [\n\t\t1,\n\t\t2\n\t],\n\t\"b\": \"<c>\"\n}", string(data))
+	}
+
+	data, err = nested.MarshalJSONIndent("", "")
+	if assert.Nil(t, err) {
+		assert.Equal(t, nested.ToJSONString(), string(data))
+	}
+
+	nested = &Nested{isArray: true, array: []*Nested{{isValue: true, value: math.Inf(1)}}}
+
+	_, err = nested.MarshalJSONIndent("", "  ")
+	assert.EqualError(t, err, "json: unsupported value: +Inf")
+}
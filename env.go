@@ -0,0 +1,214 @@
+package nested
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Загрузка переменных окружения с префиксом prefix в объект.
+//
+// Имя переменной без префикса разбивается разделителем separator на цепочку ключей, которые сопоставляются
+// с существующими ключами объекта без учета регистра (см. [Nested.FoldKeys]), а отсутствующие ключи
+// приводятся к нижнему регистру. Переменные с пустыми ключами в цепочке (например, "APP_DB____HOST"
+// при разделителе "__") пропускаются. Значения конвертируются по тем же правилам, что и скалярные значения в [FromJSONString].
+//
+// Существующие значения перезаписываются, промежуточные объекты создаются при необходимости.
+// Переменные применяются в алфавитном порядке. Если значение нельзя сохранить (например, промежуточный
+// ключ в объекте является значением или массивом), вернется ошибка с именем переменной,
+// при этом уже примененные переменные останутся в объекте.
+//
+// Пример:
+//
+//	// APP_DB__HOST=localhost APP_DB__PORT=5432 APP_DB__MAXCONNS=10 APP_DEBUG=true
+//
+//	nested := FromJSONString(`{"db": {"host": "db.local", "maxConns": 5}}`)
+//	nested.LoadEnv("APP_", "__")
+//
+//	nested.ToJSONString() // {"db": {"host": "localhost", "maxConns": 10, "port": 5432}, "debug": true}
+func (j *Nested) LoadEnv(prefix, separator string) error {
+	if separator == "" {
+		return fmt.Errorf("separator must not be empty")
+	}
+
+	environ := os.Environ()
+	slices.Sort(environ)
+
+	for _, variable := range environ {
+		name, value, _ := strings.Cut(variable, "=")
+
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		keys := strings.Split(strings.ToLower(strings.TrimPrefix(name, prefix)), separator)
+		if slices.Contains(keys, "") {
+			continue
+		}
+
+		if err := j.SetValue(parseScalar(value), j.FoldKeys(keys...)...); err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Сопоставление цепочки ключей с существующими ключами объекта без учета регистра.
+//
+// Каждый ключ заменяется ключом объекта на том же уровне, совпадающим с ним без учета регистра:
+// точное совпадение имеет приоритет, иначе выбирается первый в алфавитном порядке. Ключи, для которых
+// совпадений нет, и ключи после первого отсутствующего или не являющегося объектом ключ-значение
+// возвращаются без изменений.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"db": {"maxConns": 5}}`)
+//
+//	nested.FoldKeys("DB", "maxconns", "timeout") // [db maxConns timeout]
+func (j *Nested) FoldKeys(keys ...string) []string {
+	result := make([]string, 0, len(keys))
+	current := j
+
+	for _, key := range keys {
+		if current != nil && current.IsNested() {
+			key = foldKey(current.nested, key)
+			current = current.nested[key]
+		} else {
+			current = nil
+		}
+
+		result = append(result, key)
+	}
+
+	return result
+}
+
+// Ключ словаря, совпадающий с key без учета регистра, или сам key, если совпадений нет.
+func foldKey(nested map[string]*Nested, key string) string {
+	if _, ok := nested[key]; ok {
+		return key
+	}
+
+	for _, k := range sortedKeys(nested) {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+
+	return key
+}
+
+// Загрузка значений флагов командной строки в объект по цепочкам ключей.
+//
+// paths сопоставляет имени флага цепочку ключей, по которой сохраняется его значение.
+// Применяются только флаги, явно переданные при разборе flags.Parse, поэтому значения флагов по умолчанию
+// не перезаписывают значения в объекте. Флаги без цепочки ключей в paths игнорируются.
+//
+// Для флагов, реализующих [flag.Getter] (все стандартные типы), сохраняется типизированное значение
+// (например, int или time.Duration), для остальных значение конвертируется из строки по правилам [FromJSONString].
+// Флаги, которые не хранят значение (например, [flag.FlagSet.Func]), сохраняются исходной строкой аргумента,
+// если до разбора был вызван [RecordFlags], иначе их значение будет пустым.
+//
+// Возвращает ошибку, если флаг из paths не определен в flags.
+//
+// Пример:
+//
+//	flags := flag.NewFlagSet("app", flag.ExitOnError)
+//	flags.Int("port", 8080, "listen port")
+//	flags.Parse([]string{"-port", "9090"})
+//
+//	nested := Nested{}
+//	nested.LoadFlags(flags, map[string][]string{"port": {"server", "port"}})
+//
+//	nested.GetValue("server", "port") // 9090, nil
+func (j *Nested) LoadFlags(flags *flag.FlagSet, paths map[string][]string) error {
+	for name := range paths {
+		if flags.Lookup(name) == nil {
+			return fmt.Errorf("flag '%s' is not defined", name)
+		}
+	}
+
+	var err error
+
+	flags.Visit(func(f *flag.Flag) {
+		keys, ok := paths[f.Name]
+		if !ok || err != nil {
+			return
+		}
+
+		var value any
+		switch v := f.Value.(type) {
+		case *recordedFlag:
+			value = v.raw
+		case flag.Getter:
+			value = v.Get()
+		default:
+			value = parseScalar(f.Value.String())
+		}
+
+		if setErr := j.SetValue(value, keys...); setErr != nil {
+			err = fmt.Errorf("flag '%s': %s", f.Name, setErr.Error())
+		}
+	})
+
+	return err
+}
+
+// Сохранение исходных строк аргументов для флагов, не реализующих [flag.Getter].
+//
+// Такие флаги (например, [flag.FlagSet.Func] и [flag.FlagSet.BoolFunc]) не хранят переданное значение,
+// поэтому [Nested.LoadFlags] не может его получить. Функция вызывается после определения флагов
+// и до flags.Parse, поведение самих флагов не меняется.
+//
+// Пример:
+//
+//	flags.Func("level", "log level", parseLevel)
+//
+//	RecordFlags(flags)
+//	flags.Parse(os.Args[1:])
+//
+//	nested.LoadFlags(flags, map[string][]string{"level": {"log", "level"}})
+func RecordFlags(flags *flag.FlagSet) {
+	flags.VisitAll(func(f *flag.Flag) {
+		switch f.Value.(type) {
+		case flag.Getter, *recordedFlag:
+			return
+		}
+
+		f.Value = &recordedFlag{Value: f.Value}
+	})
+}
+
+// Флаг с сохранением последней переданной строки.
+type recordedFlag struct {
+	flag.Value
+	raw string
+}
+
+func (f *recordedFlag) Set(value string) error {
+	if err := f.Value.Set(value); err != nil {
+		return err
+	}
+
+	f.raw = value
+
+	return nil
+}
+
+// Значение для справки, в том числе для нулевого флага, который создает flag.PrintDefaults.
+func (f *recordedFlag) String() string {
+	if f.Value == nil {
+		return ""
+	}
+
+	return f.Value.String()
+}
+
+// Признак логического флага, который можно передать без значения (например, [flag.FlagSet.BoolFunc]).
+func (f *recordedFlag) IsBoolFlag() bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
@@ -0,0 +1,126 @@
+package nested
+
+import (
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadEnv(t *testing.T) {
+	t.Setenv("NESTED_TEST_DB__HOST", "localhost")
+	t.Setenv("NESTED_TEST_DB__PORT", "5432")
+	t.Setenv("NESTED_TEST_DEBUG", "true")
+	t.Setenv("NESTED_TEST_RATIO", "0.5")
+	t.Setenv("NESTED_TEST_NAME", "my=app")
+	t.Setenv("NESTED_TEST_EMPTY____KEY", "skipped")
+	t.Setenv("NESTED_OTHER", "skipped")
+
+	nested := FromJSONString(`{"db": {"host": "db.local", "user": "app"}}`)
+
+	if assert.Nil(t, nested.LoadEnv("NESTED_TEST_", "__")) {
+		assert.Equal(t, FromJSONString(`{
+			"db": {"host": "localhost", "port": 5432, "user": "app"},
+			"debug": true,
+			"ratio": 0.5,
+			"name": "my=app"
+		}`).ToObject(), nested.ToObject())
+	}
+
+	// ключи сопоставляются с существующими без учета регистра
+	nested = FromJSONString(`{"db": {"maxConns": 5, "Host": "a", "host": "b"}, "Debug": false}`)
+
+	if assert.Nil(t, nested.LoadEnv("NESTED_TEST_", "__")) {
+		assert.Equal(t, FromJSONString(`{
+			"db": {"maxConns": 5, "Host": "a", "host": "localhost", "port": 5432},
+			"Debug": true,
+			"ratio": 0.5,
+			"name": "my=app"
+		}`).ToObject(), nested.ToObject())
+	}
+
+	t.Setenv("NESTED_TEST_DB__MAXCONNS", "10")
+	assert.Nil(t, nested.LoadEnv("NESTED_TEST_", "__"))
+	assert.Equal(t, 10, nested.nested["db"].nested["maxConns"].value)
+
+	t.Setenv("NESTED_TEST_DB", "value")
+
+	nested = &Nested{}
+	assert.EqualError(t, nested.LoadEnv("NESTED_TEST_", "__"), "NESTED_TEST_DB__HOST: db: is value")
+
+	assert.EqualError(t, nested.LoadEnv("NESTED_TEST_", ""), "separator must not be empty")
+
+	// числа разбираются без потери точности
+	t.Setenv("NESTED_NUMBER_BIG", "123456789012")
+	t.Setenv("NESTED_NUMBER_PI", "3.14")
+
+	nested = &Nested{}
+	if assert.Nil(t, nested.LoadEnv("NESTED_NUMBER_", "__")) {
+		assert.Equal(t, 123456789012, nested.nested["big"].value)
+		assert.Equal(t, 3.14, nested.nested["pi"].value)
+	}
+}
+
+func Test_FoldKeys(t *testing.T) {
+	nested := FromJSONString(`{"db": {"maxConns": 5, "MaxConns": 6, "list": [1]}, "DB": 1}`)
+
+	assert.Equal(t, []string{"db", "MaxConns"}, nested.FoldKeys("db", "MAXCONNS"))
+	assert.Equal(t, []string{"DB"}, nested.FoldKeys("DB"))
+	assert.Equal(t, []string{"DB", "x"}, nested.FoldKeys("Db", "x"))
+	assert.Equal(t, []string{"db", "list", "Item"}, nested.FoldKeys("db", "LIST", "Item"))
+	assert.Equal(t, []string{"db", "missing", "KEY"}, nested.FoldKeys("db", "missing", "KEY"))
+	assert.Equal(t, []string{}, nested.FoldKeys())
+}
+
+func Test_LoadFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("host", "0.0.0.0", "")
+	flags.Int("port", 8080, "")
+	flags.Duration("timeout", time.Second, "")
+	flags.Bool("debug", false, "")
+	flags.Func("level", "", func(string) error { return nil })
+	flags.BoolFunc("trace", "", func(string) error { return nil })
+	flags.String("unbound", "", "")
+
+	RecordFlags(flags)
+	RecordFlags(flags)
+
+	assert.Nil(t, flags.Parse([]string{"-port", "9090", "-timeout", "5s", "-level", "3", "-trace", "-unbound", "x"}))
+
+	paths := map[string][]string{
+		"host":    {"server", "host"},
+		"port":    {"server", "port"},
+		"timeout": {"server", "timeout"},
+		"debug":   {"debug"},
+		"level":   {"log", "level"},
+		"trace":   {"log", "trace"},
+	}
+
+	nested := FromJSONString(`{"server": {"host": "localhost"}}`)
+
+	if assert.Nil(t, nested.LoadFlags(flags, paths)) {
+		assert.Equal(t, (&Nested{nested: map[string]*Nested{
+			"server": {nested: map[string]*Nested{
+				"host":    {isValue: true, value: "localhost"},
+				"port":    {isValue: true, value: 9090},
+				"timeout": {isValue: true, value: 5 * time.Second},
+			}},
+			"log": {nested: map[string]*Nested{
+				"level": {isValue: true, value: "3"},
+				"trace": {isValue: true, value: "true"},
+			}},
+		}}).ToObject(), nested.ToObject())
+	}
+
+	// справка по флагам не меняется
+	usage := &strings.Builder{}
+	flags.SetOutput(usage)
+	flags.PrintDefaults()
+	assert.NotContains(t, usage.String(), "panic")
+	assert.Contains(t, usage.String(), "-trace")
+
+	assert.EqualError(t, nested.LoadFlags(flags, map[string][]string{"missing": {"a"}}), "flag 'missing' is not defined")
+	assert.EqualError(t, nested.LoadFlags(flags, map[string][]string{"port": {"server", "host", "port"}}), "flag 'port': server.host: is value")
+}
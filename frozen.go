@@ -0,0 +1,310 @@
+package nested
+
+import (
+	"fmt"
+)
+
+// Неизменяемый объект со структурным разделением.
+//
+// Функции изменения ([Frozen.With], [Frozen.WithValue], [Frozen.Without]) не меняют объект, а возвращают
+// новую версию, в которой копируются только объекты на пути от корня до изменяемого ключа, а все остальные
+// поддеревья общие с исходной версией. Каждый скопированный объект копирует свой словарь ключей целиком,
+// поэтому изменение стоит O(глубина × ширина), где ширина - число ключей в объектах на пути.
+// Для дерева с 10 ключами на уровне это на порядки дешевле [Nested.Clone], но изменение в словаре
+// с 10000 ключами стоит около миллисекунды (см. Benchmark_FrozenWithWide). Для частых изменений
+// широких словарей лучше подходит изменяемый [Nested].
+//
+// Так как объекты не меняются после создания, их можно без блокировок передавать между горутинами
+// и хранить как снимки состояния. Ключи в цепочках, как и у [Nested], проходят только через объекты
+// ключ-значение. Нулевые указатели во вложенных объектах, как и у [Nested], соответствуют null.
+//
+// Пример:
+//
+//	v1 := FromJSONString(`{"db": {"host": "a"}, "cache": {"size": 10}}`).Freeze()
+//	v2, _ := v1.WithValue("b", "db", "host")
+//
+//	v1.GetValue("db", "host") // "a"
+//	v2.GetValue("db", "host") // "b"
+//	// v1 и v2 используют общий объект "cache"
+type Frozen struct {
+	isValue bool
+	isArray bool
+	nested  map[string]*Frozen
+	array   []*Frozen
+	value   any
+}
+
+// Создание неизменяемой копии объекта.
+//
+// Копирует объект так же, как [Nested.Clone]. Для нулевого указателя возвращается пустой объект ключ-значение.
+func (j *Nested) Freeze() *Frozen {
+	if j == nil {
+		return &Frozen{}
+	}
+
+	return freeze(j)
+}
+
+// Создание изменяемой копии объекта.
+//
+// Обратна [Nested.Freeze]: для любого объекта j объект j.Freeze().Thaw() совпадает с j.Clone(),
+// кроме порядка добавления ключей (см. [Encoder.SetSortKeys]), который не сохраняется.
+func (f *Frozen) Thaw() *Nested {
+	if f == nil {
+		return nil
+	}
+
+	array, nested, _ := copyChildren(f.array, f.nested, func(_ string, element *Frozen) (*Nested, bool, error) {
+		return element.Thaw(), true, nil
+	})
+
+	return &Nested{isValue: f.isValue, isArray: f.isArray, nested: nested, array: array, value: f.value}
+}
+
+// Проверка, является ли объект массивом.
+func (f *Frozen) IsArray() bool {
+	return f.isArray
+}
+
+// Проверка, является ли объект скалярным значением.
+func (f *Frozen) IsValue() bool {
+	return f.isValue
+}
+
+// Проверка, что объект имеет тип ключ-значение.
+func (f *Frozen) IsNested() bool {
+	return !f.isArray && !f.isValue
+}
+
+// Размер объекта, см. [Nested.Length].
+func (f *Frozen) Length() int {
+	if f.isValue {
+		return -1
+	}
+
+	if f.isArray {
+		return len(f.array)
+	}
+
+	return len(f.nested)
+}
+
+// Получение ключей объекта ключ-значение в алфавитном порядке.
+//
+// Для массивов и значений возвращается nil.
+func (f *Frozen) Keys() []string {
+	if !f.IsNested() {
+		return nil
+	}
+
+	return sortedKeys(f.nested)
+}
+
+// Получение копии среза элементов массива.
+//
+// Для объектов ключ-значение и значений возвращается nil.
+func (f *Frozen) Elements() []*Frozen {
+	if !f.isArray {
+		return nil
+	}
+
+	return append([]*Frozen{}, f.array...)
+}
+
+// Получение вложенного объекта по цепочке ключей, см. [Nested.Get].
+func (f *Frozen) Get(keys ...string) (*Frozen, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keys list must contain at least one key")
+	}
+
+	current := f
+
+	for index, k := range keys {
+		if err := frozenNestedError(current, keys[:index]); err != nil {
+			return nil, err
+		}
+
+		next, ok := current.nested[k]
+		if !ok {
+			return nil, pathError(keys[:index], "key '%s' not found", k)
+		}
+
+		current = next
+	}
+
+	return current, nil
+}
+
+// Получение скалярного значения по цепочке ключей, см. [Nested.GetValue].
+func (f *Frozen) GetValue(keys ...string) (any, error) {
+	current := f
+
+	if len(keys) > 0 {
+		var err error
+
+		current, err = f.Get(keys...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if current == nil {
+		return nil, nil
+	}
+
+	if current.isArray {
+		return nil, pathError(keys, "is array")
+	}
+
+	if !current.isValue {
+		return nil, pathError(keys, "is nested")
+	}
+
+	return current.value, nil
+}
+
+// Новая версия с объектом value по цепочке ключей.
+//
+// Как и [Nested.Set], создает объекты для отсутствующих ключей и возвращает ошибку, если исходный
+// или один из промежуточных объектов является массивом или значением. Нулевой указатель value
+// сохраняется как пустой объект ключ-значение.
+func (f *Frozen) With(value *Frozen, keys ...string) (*Frozen, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keys list must contain at least one key")
+	}
+
+	if value == nil {
+		value = &Frozen{}
+	}
+
+	return f.with(value, keys, 0)
+}
+
+// Новая версия со скалярным значением по цепочке ключей, см. [Frozen.With].
+func (f *Frozen) WithValue(value any, keys ...string) (*Frozen, error) {
+	return f.With(&Frozen{isValue: true, value: value}, keys...)
+}
+
+// Новая версия без объекта по цепочке ключей.
+//
+// Как и [Nested.Delete], возвращает ошибку при отсутствии промежуточного ключа.
+// Если отсутствует последний ключ, возвращается исходная версия.
+func (f *Frozen) Without(keys ...string) (*Frozen, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keys list must contain at least one key")
+	}
+
+	return f.without(keys, 0)
+}
+
+// Конвертация в JSON-строку, см. [Nested.ToJSONString].
+func (f *Frozen) ToJSONString() string {
+	return f.Thaw().ToJSONString()
+}
+
+// Рекурсивное построение новой версии с объектом по цепочке ключей начиная с keys[depth].
+func (f *Frozen) with(value *Frozen, keys []string, depth int) (*Frozen, error) {
+	if err := frozenNestedError(f, keys[:depth]); err != nil {
+		return nil, err
+	}
+
+	k := keys[depth]
+
+	if depth < len(keys)-1 {
+		next, ok := f.nested[k]
+		if !ok {
+			next = &Frozen{}
+		}
+
+		var err error
+
+		value, err = next.with(value, keys, depth+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Frozen{nested: make(map[string]*Frozen, len(f.nested)+1)}
+	for key, element := range f.nested {
+		result.nested[key] = element
+	}
+
+	result.nested[k] = value
+
+	return result, nil
+}
+
+// Рекурсивное построение новой версии без объекта по цепочке ключей начиная с keys[depth].
+func (f *Frozen) without(keys []string, depth int) (*Frozen, error) {
+	if err := frozenNestedError(f, keys[:depth]); err != nil {
+		return nil, err
+	}
+
+	k := keys[depth]
+	last := depth == len(keys)-1
+
+	next, ok := f.nested[k]
+	if !ok {
+		if last {
+			return f, nil
+		}
+
+		return nil, pathError(keys[:depth], "key '%s' not found", k)
+	}
+
+	var updated *Frozen
+
+	if !last {
+		var err error
+
+		updated, err = next.without(keys, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		if updated == next {
+			return f, nil
+		}
+	}
+
+	result := &Frozen{nested: make(map[string]*Frozen, len(f.nested))}
+	for key, element := range f.nested {
+		result.nested[key] = element
+	}
+
+	if last {
+		delete(result.nested, k)
+	} else {
+		result.nested[k] = updated
+	}
+
+	return result, nil
+}
+
+// Рекурсивное создание неизменяемой копии с сохранением нулевых указателей.
+func freeze(j *Nested) *Frozen {
+	if j == nil {
+		return nil
+	}
+
+	array, nested, _ := copyChildren(j.array, j.nested, func(_ string, element *Nested) (*Frozen, bool, error) {
+		return freeze(element), true, nil
+	})
+
+	return &Frozen{isValue: j.isValue, isArray: j.isArray, nested: nested, array: array, value: j.value}
+}
+
+// Ошибка для объекта по цепочке ключей path, если он не является объектом ключ-значение.
+//
+// Нулевой указатель считается значением null.
+func frozenNestedError(f *Frozen, path []string) error {
+	if f == nil || f.isValue {
+		return pathError(path, "is value")
+	}
+
+	if f.isArray {
+		return pathError(path, "is array")
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package nested
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Frozen(t *testing.T) {
+	source := FromJSONString(`{"db": {"host": "a", "port": 1}, "cache": {"size": 10}, "list": [1, {"x": 2}], "flag": true}`)
+
+	v1 := source.Freeze()
+
+	// изменение исходного объекта не влияет на неизменяемую копию
+	assert.Nil(t, source.SetValue("changed", "db", "host"))
+	assert.Equal(t, `{"cache":{"size":10},"db":{"host":"a","port":1},"flag":true,"list":[1,{"x":2}]}`, v1.ToJSONString())
+
+	v2, err := v1.WithValue("b", "db", "host")
+	assert.Nil(t, err)
+
+	v3, err := v2.With(FromJSONString(`{"level": "debug"}`).Freeze(), "log", "settings")
+	assert.Nil(t, err)
+
+	v4, err := v3.Without("cache", "size")
+	assert.Nil(t, err)
+
+	assert.Equal(t, `{"cache":{"size":10},"db":{"host":"a","port":1},"flag":true,"list":[1,{"x":2}]}`, v1.ToJSONString())
+	assert.Equal(t, `{"cache":{"size":10},"db":{"host":"b","port":1},"flag":true,"list":[1,{"x":2}]}`, v2.ToJSONString())
+	assert.Equal(t, `{"cache":{"size":10},"db":{"host":"b","port":1},"flag":true,"list":[1,{"x":2}],"log":{"settings":{"level":"debug"}}}`, v3.ToJSONString())
+	assert.Equal(t, `{"cache":{},"db":{"host":"b","port":1},"flag":true,"list":[1,{"x":2}],"log":{"settings":{"level":"debug"}}}`, v4.ToJSONString())
+
+	// неизмененные поддеревья общие для всех версий
+	assert.Same(t, v1.nested["cache"], v2.nested["cache"])
+	assert.Same(t, v1.nested["list"], v4.nested["list"])
+	assert.Same(t, v1.nested["db"].nested["port"], v4.nested["db"].nested["port"])
+	assert.Same(t, v2.nested["db"], v4.nested["db"])
+	assert.NotSame(t, v1.nested["db"], v2.nested["db"])
+
+	// удаление отсутствующего ключа возвращает ту же версию
+	same, err := v4.Without("cache", "missing")
+	assert.Nil(t, err)
+	assert.Same(t, v4, same)
+
+	value, err := v2.GetValue("db", "host")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", value)
+
+	assert.Equal(t, []string{"cache", "db", "flag", "list"}, v1.Keys())
+	assert.Equal(t, 2, len(v1.nested["list"].Elements()))
+	assert.Nil(t, v1.nested["flag"].Keys())
+	assert.Equal(t, -1, v1.nested["flag"].Length())
+	assert.True(t, v1.nested["list"].IsArray())
+	assert.True(t, v1.IsNested())
+
+	thawed := v4.Thaw()
+	assert.True(t, Equals(thawed, FromJSONString(v4.ToJSONString())))
+
+	assert.Nil(t, thawed.SetValue(2, "cache", "size"))
+	assert.Equal(t, 0, v4.nested["cache"].Length())
+}
+
+func Test_FrozenErrors(t *testing.T) {
+	frozen := FromJSONString(`{"a": {"b": 1, "c": [1]}}`).Freeze()
+
+	cases := []struct {
+		err      func() error
+		expected string
+	}{
+		{func() error { _, err := frozen.WithValue(1); return err }, "keys list must contain at least one key"},
+		{func() error { _, err := frozen.WithValue(1, "a", "b", "c"); return err }, "a.b: is value"},
+		{func() error { _, err := frozen.WithValue(1, "a", "c", "d"); return err }, "a.c: is array"},
+		{func() error { _, err := frozen.Without(); return err }, "keys list must contain at least one key"},
+		{func() error { _, err := frozen.Without("x", "y"); return err }, "key 'x' not found"},
+		{func() error { _, err := frozen.Without("a", "x", "y"); return err }, "a: key 'x' not found"},
+		{func() error { _, err := frozen.Without("a", "b", "c"); return err }, "a.b: is value"},
+		{func() error { _, err := frozen.Get("a", "b", "c"); return err }, "a.b: is value"},
+		{func() error { _, err := frozen.Get("a", "x"); return err }, "a: key 'x' not found"},
+		{func() error { _, err := frozen.GetValue("a", "c"); return err }, "a.c: is array"},
+		{func() error { _, err := frozen.GetValue("a"); return err }, "a: is nested"},
+		{func() error { _, err := frozen.nested["a"].nested["b"].WithValue(1, "x"); return err }, "is value"},
+	}
+
+	for _, e := range cases {
+		assert.EqualError(t, e.err(), e.expected)
+	}
+
+	// ошибки не меняют исходную версию
+	assert.Equal(t, `{"a":{"b":1,"c":[1]}}`, frozen.ToJSONString())
+
+	empty, err := (&Frozen{}).With(nil, "a")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":{}}`, empty.ToJSONString())
+}
+
+func Test_FrozenConcurrent(t *testing.T) {
+	base := FromJSONString(`{"counters": {}}`).Freeze()
+
+	versions := make([]*Frozen, 8)
+
+	var wg sync.WaitGroup
+	for index := range versions {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			version := base
+			for step := 0; step < 100; step++ {
+				version, _ = version.WithValue(step, "counters", strconv.Itoa(index))
+			}
+
+			versions[index] = version
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, `{"counters":{}}`, base.ToJSONString())
+
+	for index, version := range versions {
+		value, err := version.GetValue("counters", strconv.Itoa(index))
+		assert.Nil(t, err)
+		assert.Equal(t, 99, value)
+		assert.Equal(t, 1, version.nested["counters"].Length())
+	}
+}
+
+func Test_Clone(t *testing.T) {
+	source := FromJSONString(`{"a": {"b": [1, {"c": 2}]}}`)
+	source.Subscribe(func(ChangeEvent) {})
+
+	clone := source.Clone()
+	assert.True(t, Equals(source, clone))
+	assert.Nil(t, clone.observation)
+
+	assert.Nil(t, clone.SetValue(3, "a", "d"))
+	array, _ := clone.GetArray("a", "b")
+	assert.Nil(t, array[1].SetValue(4, "c"))
+
+	assert.Equal(t, `{"a":{"b":[1,{"c":2}]}}`, source.ToJSONString())
+	assert.Nil(t, (*Nested)(nil).Clone())
+}
+
+// Тестовый объект для сравнения производительности: width ключей на каждом из depth уровней.
+func benchmarkNested(width, depth int) *Nested {
+	if depth == 0 {
+		return &Nested{isValue: true, value: 0}
+	}
+
+	result := &Nested{nested: map[string]*Nested{}}
+	for index := 0; index < width; index++ {
+		result.nested[fmt.Sprintf("k%d", index)] = benchmarkNested(width, depth-1)
+	}
+
+	return result
+}
+
+func Benchmark_FrozenWith(b *testing.B) {
+	frozen := benchmarkNested(10, 4).Freeze()
+
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := frozen.WithValue(index, "k1", "k2", "k3", "k4"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Стоимость изменения растет с числом ключей в объектах на пути, так как их словари копируются.
+func Benchmark_FrozenWithWide(b *testing.B) {
+	frozen := benchmarkNested(10000, 1).Freeze()
+
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := frozen.WithValue(index, "k1"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_CloneThenSet(b *testing.B) {
+	nested := benchmarkNested(10, 4)
+
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+		if err := nested.Clone().SetValue(index, "k1", "k2", "k3", "k4"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_FrozenWithout(b *testing.B) {
+	frozen := benchmarkNested(10, 4).Freeze()
+
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := frozen.Without("k1", "k2", "k3", "k4"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_CloneThenDelete(b *testing.B) {
+	nested := benchmarkNested(10, 4)
+
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+		if err := nested.Clone().Delete("k1", "k2", "k3", "k4"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Test_FreezeNil(t *testing.T) {
+	source := &Nested{nested: map[string]*Nested{
+		"a": nil,
+		"b": {isArray: true, array: []*Nested{nil, {isValue: true, value: 1}}},
+	}}
+
+	frozen := source.Freeze()
+	assert.Nil(t, frozen.nested["a"])
+	assert.Nil(t, frozen.nested["b"].array[0])
+
+	// нулевые указатели сохраняются при обратном преобразовании
+	assert.Equal(t, source.Clone(), frozen.Thaw())
+
+	value, err := frozen.GetValue("a")
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	_, err = frozen.Get("a", "x")
+	assert.EqualError(t, err, "a: is value")
+
+	updated, err := frozen.WithValue(2, "c")
+	assert.Nil(t, err)
+	assert.Nil(t, updated.nested["a"])
+}
@@ -0,0 +1,167 @@
+package nested
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+	"strconv"
+)
+
+// Размер хеша объекта в байтах.
+const HashSize = sha256.Size
+
+// Префиксы, по которым различаются хеши значений, массивов и объектов ключ-значение.
+const (
+	hashTagValue byte = iota
+	hashTagArray
+	hashTagNested
+)
+
+// Вычисление SHA-256 хеша объекта.
+//
+// Хеш зависит только от содержимого: порядок ключей в словарях не учитывается,
+// а числа сравниваются по значению, поэтому 1 и 1.0 дают одинаковый хеш.
+// Скалярные значения хешируются в виде канонического JSON (см. [Nested.ToCanonicalJSON]),
+// целые числа, которые нельзя точно представить в float64, записываются без потери точности.
+//
+// Хеши вычисляются для каждого вложенного объекта отдельно и кешируются, поэтому после изменения
+// пересчитываются только хеши измененного объекта и его родительских объектов. Для этого при первом вызове
+// включается режим отслеживания изменений (см. [Nested.Observe]), и кеш сбрасывается по родительским объектам
+// при любом изменении через Set, Delete, ArrayAdd и производные от них функции, в том числе у вложенных
+// объектов, полученных через Get или ArrayGet. Объект, сохраненный в нескольких местах, связан только
+// с последним родительским объектом, поэтому при его изменении через другие родительские объекты
+// кеш сбрасывается только по цепочке ключей вызова.
+//
+// Возвращает ошибку для NaN, бесконечностей и значений, которые нельзя сконвертировать в JSON.
+//
+// Пример:
+//
+//	a := FromJSONString(`{"a": 1, "b": [true]}`)
+//	b := FromJSONString(`{"b": [true], "a": 1.0}`)
+//
+//	hashA, _ := a.Hash()
+//	hashB, _ := b.Hash()
+//	hashA == hashB // true
+func (j *Nested) Hash() ([HashSize]byte, error) {
+	j.Observe()
+
+	sum, err := j.computeHash([]string{})
+	if err != nil {
+		return [HashSize]byte{}, err
+	}
+
+	return *sum, nil
+}
+
+// Вычисление 64-битного хеша объекта.
+//
+// Содержит первые 8 байт хеша [Nested.Hash] и удобен для использования в качестве ключа словаря.
+func (j *Nested) Hash64() (uint64, error) {
+	sum, err := j.Hash()
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(sum[:8]), nil
+}
+
+// Рекурсивное вычисление хеша с использованием кеша.
+//
+// path - цепочка ключей до объекта для текста ошибки. Отсутствующий (nil) объект хешируется как значение null.
+func (j *Nested) computeHash(path []string) (*[HashSize]byte, error) {
+	if j == nil {
+		return (&Nested{isValue: true}).computeHash(path)
+	}
+
+	if j.hash != nil {
+		return j.hash, nil
+	}
+
+	hasher := sha256.New()
+
+	switch {
+	case j.IsValue():
+		buffer := &bytes.Buffer{}
+		if err := writeHashValue(buffer, j.value); err != nil {
+			return nil, pathError(path, "%w", err)
+		}
+
+		hasher.Write([]byte{hashTagValue})
+		hasher.Write(buffer.Bytes())
+	case j.IsArray():
+		hasher.Write([]byte{hashTagArray})
+
+		for index, element := range j.array {
+			sum, err := element.computeHash(append(slices.Clone(path), strconv.Itoa(index)))
+			if err != nil {
+				return nil, err
+			}
+
+			hasher.Write(sum[:])
+		}
+	default:
+		hasher.Write([]byte{hashTagNested})
+
+		for _, k := range sortedKeys(j.nested) {
+			sum, err := j.nested[k].computeHash(append(slices.Clone(path), k))
+			if err != nil {
+				return nil, err
+			}
+
+			hasher.Write(binary.BigEndian.AppendUint64(nil, uint64(len(k))))
+			hasher.Write([]byte(k))
+			hasher.Write(sum[:])
+		}
+	}
+
+	j.hash = (*[HashSize]byte)(hasher.Sum(nil))
+
+	return j.hash, nil
+}
+
+// Сброс кешированных хешей у объекта и у всех его родительских объектов.
+func (j *Nested) resetHash() {
+	for current := j; current != nil; current = current.observation.parent {
+		current.hash = nil
+
+		if current.observation == nil {
+			return
+		}
+	}
+}
+
+// Запись скалярного значения для хеширования.
+//
+// В отличие от канонического JSON, целые числа вне диапазона точного представления в float64
+// не приводят к ошибке, а записываются десятичной строкой. Такая запись не совпадает ни с одним числом float64.
+func writeHashValue(buffer *bytes.Buffer, value any) error {
+	switch number := value.(type) {
+	case int:
+		writeHashInt(buffer, int64(number))
+	case int64:
+		writeHashInt(buffer, number)
+	case uint:
+		writeHashUint(buffer, uint64(number))
+	case uint64:
+		writeHashUint(buffer, number)
+	default:
+		return writeCanonical(buffer, value)
+	}
+
+	return nil
+}
+
+// Запись целого числа для хеширования.
+func writeHashInt(buffer *bytes.Buffer, value int64) {
+	if err := writeCanonicalInt(buffer, value); err != nil {
+		buffer.WriteString(strconv.FormatInt(value, 10))
+	}
+}
+
+// Запись беззнакового целого числа для хеширования.
+func writeHashUint(buffer *bytes.Buffer, value uint64) {
+	if err := writeCanonicalUint(buffer, value); err != nil {
+		buffer.WriteString(strconv.FormatUint(value, 10))
+	}
+}
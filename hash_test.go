@@ -0,0 +1,140 @@
+package nested
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Hash(t *testing.T) {
+	a := Nested{}
+	a.SetValue(1, "a")
+	a.SetValue("string", "b", "c")
+	a.SetArray([]*Nested{{isValue: true, value: true}, nil}, "d")
+
+	b := Nested{}
+	b.SetArray([]*Nested{{isValue: true, value: true}, {isValue: true, value: nil}}, "d")
+	b.SetValue("string", "b", "c")
+	b.SetValue(1.0, "a")
+
+	hashA, err := a.Hash()
+	assert.Nil(t, err)
+
+	hashB, err := b.Hash()
+	assert.Nil(t, err)
+
+	assert.Equal(t, hashA, hashB)
+
+	hash64, err := a.Hash64()
+	if assert.Nil(t, err) {
+		assert.Equal(t, binary.BigEndian.Uint64(hashA[:8]), hash64)
+	}
+
+	// хеши различаются для разных значений, типов и порядка элементов в массиве
+	different := []*Nested{
+		FromJSONString(`{"a": 1}`),
+		FromJSONString(`{"a": "1"}`),
+		FromJSONString(`{"a": [1]}`),
+		FromJSONString(`{"a": {"1": 1}}`),
+		FromJSONString(`{"a": [1, 2]}`),
+		FromJSONString(`{"a": [2, 1]}`),
+		FromJSONString(`{"a": [[1], 2]}`),
+		FromJSONString(`{"a": [[1, 2]]}`),
+		FromJSONString(`{"ab": 1}`),
+		FromJSONString(`{"a": null}`),
+		FromJSONString(`{}`),
+		FromJSONString(`[]`),
+		{isValue: true, value: 9007199254740992},
+		{isValue: true, value: 9007199254740993},
+		{isValue: true, value: uint64(math.MaxUint64)},
+	}
+
+	hashes := map[[HashSize]byte]int{}
+	for index, nested := range different {
+		hash, err := nested.Hash()
+		if assert.Nil(t, err, index) {
+			previous, ok := hashes[hash]
+			assert.False(t, ok, "%d and %d have equal hashes", previous, index)
+			hashes[hash] = index
+		}
+	}
+
+	nested := Nested{}
+	nested.SetValue(math.NaN(), "a", "b")
+
+	_, err = nested.Hash()
+	assert.EqualError(t, err, "a.b: unsupported number NaN")
+
+	array := FromJSONString(`{"a": [1, {"b": 2}]}`)
+	element, _ := array.ArrayGet(1, "a")
+	assert.Nil(t, element.SetValue(math.Inf(1), "b"))
+	_, err = array.Hash()
+	assert.EqualError(t, err, "a.1.b: unsupported number +Inf")
+}
+
+func Test_HashAfterMutation(t *testing.T) {
+	nested := FromJSONString(`{"a": {"b": {"c": 1}, "array": [1]}, "d": 2}`)
+
+	mutations := []struct {
+		mutate   func(*Nested) error
+		expected string
+	}{
+		{func(j *Nested) error { return j.SetValue(2, "a", "b", "c") }, `{"a": {"b": {"c": 2}, "array": [1]}, "d": 2}`},
+		{func(j *Nested) error { return j.Delete("a", "b", "c") }, `{"a": {"b": {}, "array": [1]}, "d": 2}`},
+		{func(j *Nested) error { return j.ArrayAddValue(2, "a", "array") }, `{"a": {"b": {}, "array": [1, 2]}, "d": 2}`},
+		{func(j *Nested) error { return j.SetArray([]*Nested{}, "a", "array") }, `{"a": {"b": {}, "array": []}, "d": 2}`},
+		{func(j *Nested) error { return j.Delete("d") }, `{"a": {"b": {}, "array": []}}`},
+		{func(j *Nested) error {
+			return j.ArrayDelete(func(*Nested) bool { return true }, "a", "array")
+		}, `{"a": {"b": {}, "array": []}}`},
+		{func(j *Nested) error { return j.Clear() }, `{}`},
+	}
+
+	for _, m := range mutations {
+		_, err := nested.Hash()
+		assert.Nil(t, err)
+
+		if assert.Nil(t, m.mutate(nested)) {
+			assert.Equal(t, mustHash(t, FromJSONString(m.expected)), mustHash(t, nested), m.expected)
+		}
+	}
+
+	// изменение элемента массива, полученного по указателю
+	nested = FromJSONString(`{"a": [{"x": 1}]}`)
+	mustHash(t, nested)
+
+	element, _ := nested.ArrayGet(0, "a")
+	assert.Nil(t, element.SetValue(2, "x"))
+	assert.Equal(t, mustHash(t, FromJSONString(`{"a": [{"x": 2}]}`)), mustHash(t, nested))
+
+	// объект, общий для двух родителей
+	shared := FromJSONString(`{"v": 1}`)
+	first, second := &Nested{}, &Nested{}
+	assert.Nil(t, first.Set(shared, "s"))
+	assert.Nil(t, second.Set(shared, "s"))
+	mustHash(t, first)
+	mustHash(t, second)
+
+	assert.Nil(t, first.SetValue(2, "s", "v"))
+	assert.Equal(t, mustHash(t, FromJSONString(`{"s": {"v": 2}}`)), mustHash(t, second))
+
+	// вычисление хеша не изменяет содержимое объекта
+	nested = FromJSONString(`{"a": [1]}`)
+	mustHash(t, nested)
+	assert.Equal(t, `{"a":[1]}`, nested.ToJSONString())
+
+	// после изменения пересчитываются только хеши измененного объекта и его родителей
+	nested = FromJSONString(`{"a": {"b": 1}, "c": {"d": 2}}`)
+	mustHash(t, nested)
+
+	c, _ := nested.Get("c")
+	cached := c.hash
+
+	assert.Nil(t, nested.SetValue(3, "a", "b"))
+	assert.Nil(t, nested.hash)
+	assert.Nil(t, nested.nested["a"].hash)
+	assert.Same(t, cached, c.hash)
+	assert.Equal(t, mustHash(t, FromJSONString(`{"a": {"b": 3}, "c": {"d": 2}}`)), mustHash(t, nested))
+}
@@ -0,0 +1,427 @@
+package nested
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// История изменений объекта с возможностью отмены и повтора.
+//
+// Записывает все изменения объекта и вложенных в него (в том числе через объекты, полученные через Get),
+// используя режим отслеживания изменений (см. [Nested.Subscribe]). Для каждого изменения сохраняются
+// копии прежнего и нового объектов, поэтому последующие изменения и очистка удаленных элементов
+// не влияют на историю.
+//
+// Количество записей и их приблизительный суммарный размер можно ограничить, тогда самые старые записи
+// вытесняются новыми. Копии сохраняются целиком, поэтому замена большого объекта (например, SetMap
+// у корня) занимает память, пропорциональную его размеру.
+//
+// Отмена и повтор сохранения возвращают в родительский объект копию прежнего объекта, поэтому объекты,
+// полученные через Get или сохраненные через Set, не изменяются. Только изменения самого объекта истории
+// отменяются заменой его содержимого на месте. Если Set создает промежуточные объекты для отсутствующих
+// ключей, при отмене они удаляются вместе с сохраненным объектом.
+//
+// Не является конкурентно-безопасной.
+//
+// Пример:
+//
+//	history := NewHistory(nested, 100)
+//	defer history.Close()
+//
+//	history.Checkpoint("loaded")
+//
+//	nested.SetValue("Bob", "user", "name")
+//	nested.Delete("user", "age")
+//
+//	history.Undo()              // возвращает "age"
+//	history.Restore("loaded")   // возвращает "name"
+//	history.Redo()              // снова меняет "name"
+type History struct {
+	nested      *Nested
+	prefix      []string // путь объекта от корня, для которого включен режим отслеживания
+	limit       int
+	done        []historyEntry
+	undone      []historyEntry // последняя отмененная запись в конце
+	offset      int            // количество вытесненных записей
+	maxSize     int
+	size        int            // приблизительный размер записей в done и undone
+	checkpoints map[string]int // позиция в истории для каждой метки
+	applying    bool
+	unsubscribe func()
+}
+
+// Запись истории изменений.
+type historyEntry struct {
+	op       ChangeOp
+	path     []string // путь относительно объекта истории
+	previous *Nested
+	current  *Nested
+	size     int
+}
+
+// Создание истории изменений объекта.
+//
+// limit - максимальное количество записей, значение меньше или равное нулю снимает ограничение.
+// Включает режим отслеживания изменений объекта (см. [Nested.Observe]).
+func NewHistory(nested *Nested, limit int) *History {
+	h := &History{
+		nested:      nested,
+		limit:       limit,
+		checkpoints: map[string]int{},
+	}
+
+	h.unsubscribe = nested.Subscribe(h.record)
+	h.prefix = nested.observedPath()
+
+	return h
+}
+
+// Ограничение приблизительного суммарного размера записей в байтах.
+//
+// Размер оценивается по количеству объектов и длине строк в сохраненных копиях. При превышении
+// самые старые записи вытесняются; запись, размер которой больше ограничения, вытесняет всю историю
+// и сама не сохраняется. Значение меньше или равное нулю снимает ограничение.
+func (h *History) SetMaxSize(size int) {
+	h.maxSize = size
+	h.evict()
+}
+
+// Прекращение записи изменений.
+//
+// Записанная история остается доступной.
+func (h *History) Close() {
+	h.unsubscribe()
+}
+
+// Проверка, есть ли изменения для отмены.
+func (h *History) CanUndo() bool {
+	return len(h.done) > 0
+}
+
+// Проверка, есть ли отмененные изменения для повтора.
+func (h *History) CanRedo() bool {
+	return len(h.undone) > 0
+}
+
+// Отмена последнего изменения.
+//
+// Отменяющее изменение доставляется подписчикам объекта, но в историю не записывается.
+func (h *History) Undo() error {
+	if len(h.done) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+
+	entry := h.done[len(h.done)-1]
+
+	if err := h.apply(entry, true); err != nil {
+		return err
+	}
+
+	h.done = h.done[:len(h.done)-1]
+	h.undone = append(h.undone, entry)
+
+	return nil
+}
+
+// Повтор последнего отмененного изменения.
+//
+// Любое новое изменение объекта очищает список отмененных изменений.
+func (h *History) Redo() error {
+	if len(h.undone) == 0 {
+		return fmt.Errorf("nothing to redo")
+	}
+
+	entry := h.undone[len(h.undone)-1]
+
+	if err := h.apply(entry, false); err != nil {
+		return err
+	}
+
+	h.undone = h.undone[:len(h.undone)-1]
+	h.done = append(h.done, entry)
+
+	return nil
+}
+
+// Сохранение текущей позиции в истории с меткой.
+//
+// Повторное сохранение с той же меткой перезаписывает позицию. Метка удаляется, если ее позиция
+// вытеснена из истории или стала недостижимой после нового изменения.
+func (h *History) Checkpoint(label string) {
+	h.checkpoints[label] = h.position()
+}
+
+// Получение меток в порядке их позиций в истории.
+func (h *History) Checkpoints() []string {
+	labels := make([]string, 0, len(h.checkpoints))
+	for label := range h.checkpoints {
+		labels = append(labels, label)
+	}
+
+	slices.SortFunc(labels, func(a, b string) int {
+		if h.checkpoints[a] != h.checkpoints[b] {
+			return h.checkpoints[a] - h.checkpoints[b]
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	return labels
+}
+
+// Возврат к позиции с меткой отменой или повтором изменений.
+func (h *History) Restore(label string) error {
+	position, ok := h.checkpoints[label]
+	if !ok {
+		return fmt.Errorf("checkpoint '%s' not found", label)
+	}
+
+	for h.position() > position {
+		if err := h.Undo(); err != nil {
+			return err
+		}
+	}
+
+	for h.position() < position {
+		if err := h.Redo(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Конвертация примененных изменений в JSON Patch (RFC 6902).
+//
+// Патч переводит объект из состояния до самой старой записи в текущее. Пути элементов массивов
+// содержат индексы на момент изменения.
+//
+// Возвращает ошибку, если одно из значений не может быть сконвертировано в JSON (например, NaN).
+//
+// Пример:
+//
+//	nested.SetValue("Bob", "user", "name")
+//	nested.ArrayAddValue("dev", "user", "roles")
+//
+//	history.ToJSONPatch()
+//	// [{"op":"replace","path":"/user/name","value":"Bob"},{"op":"add","path":"/user/roles/1","value":"dev"}], nil
+func (h *History) ToJSONPatch() ([]byte, error) {
+	patch := &Nested{isArray: true, array: []*Nested{}}
+
+	for _, entry := range h.done {
+		op := "replace"
+
+		switch {
+		case entry.op == ChangeDelete || entry.op == ChangeRemove:
+			op = "remove"
+		case entry.op == ChangeAdd || entry.previous == nil:
+			op = "add"
+		}
+
+		operation := &Nested{nested: map[string]*Nested{
+			"op":   {isValue: true, value: op},
+			"path": {isValue: true, value: jsonPointer(entry.path)},
+		}}
+
+		if op != "remove" {
+			value := entry.current.Clone()
+			if value == nil {
+				value = &Nested{isValue: true}
+			}
+
+			operation.nested["value"] = value
+		}
+
+		patch.array = append(patch.array, operation)
+	}
+
+	return patch.MarshalJSONIndent("", "")
+}
+
+// Текущая позиция в истории с учетом вытесненных записей.
+func (h *History) position() int {
+	return h.offset + len(h.done)
+}
+
+// Запись изменения объекта.
+func (h *History) record(event ChangeEvent) {
+	if h.applying {
+		return
+	}
+
+	if len(event.Path) < len(h.prefix) || !slices.Equal(event.Path[:len(h.prefix)], h.prefix) {
+		// замена или удаление родительского объекта
+		return
+	}
+
+	path := event.Path[len(h.prefix):]
+	if len(path) == 0 && event.New != h.nested {
+		// замена или удаление самого объекта в родительском, после которой он не отслеживается
+		return
+	}
+
+	entry := historyEntry{
+		op:       event.Op,
+		path:     slices.Clone(path),
+		previous: event.Old.Clone(),
+		current:  event.New.Clone(),
+	}
+
+	entry.size = approximateSize(entry.previous) + approximateSize(entry.current)
+	for _, key := range entry.path {
+		entry.size += len(key)
+	}
+
+	for _, undone := range h.undone {
+		h.size -= undone.size
+	}
+
+	h.undone = nil
+	h.done = append(h.done, entry)
+	h.size += entry.size
+
+	for label, position := range h.checkpoints {
+		if position > h.position()-1 {
+			delete(h.checkpoints, label)
+		}
+	}
+
+	h.evict()
+}
+
+// Вытеснение самых старых записей при превышении ограничений.
+func (h *History) evict() {
+	for len(h.done) > 0 && ((h.limit > 0 && len(h.done) > h.limit) || (h.maxSize > 0 && h.size > h.maxSize)) {
+		h.size -= h.done[0].size
+		h.done[0] = historyEntry{}
+		h.done = h.done[1:]
+		h.offset++
+	}
+
+	for label, position := range h.checkpoints {
+		if position < h.offset {
+			delete(h.checkpoints, label)
+		}
+	}
+}
+
+// Применение записи истории: отмена при reverse, иначе повтор.
+func (h *History) apply(entry historyEntry, reverse bool) error {
+	h.applying = true
+	defer func() {
+		h.applying = false
+	}()
+
+	current := entry.current
+	if reverse {
+		current = entry.previous
+	}
+
+	if len(entry.path) == 0 {
+		// сам отслеживаемый объект нельзя заменить в родительском, поэтому его содержимое заменяется на месте
+		h.nested.replace(current.Clone())
+
+		return nil
+	}
+
+	parentPath, last := entry.path[:len(entry.path)-1], entry.path[len(entry.path)-1]
+
+	parent := h.nested.lookup(parentPath...)
+	if parent == nil {
+		if entry.op == ChangeSet && current != nil {
+			// промежуточные объекты могли быть удалены отменой предыдущих изменений
+			return h.nested.Set(current.Clone(), entry.path...)
+		}
+
+		return fmt.Errorf("%s: not found", strings.Join(parentPath, "."))
+	}
+
+	switch {
+	case entry.op == ChangeAdd && !parent.IsArray():
+		// объект, созданный по отсутствующему ключу вместе с промежуточными
+		if reverse {
+			return parent.Delete(last)
+		}
+
+		return parent.Set(current.Clone(), last)
+	case entry.op == ChangeAdd || entry.op == ChangeRemove:
+		index, err := strconv.Atoi(last)
+		if err != nil {
+			return err
+		}
+
+		if (entry.op == ChangeAdd) != reverse {
+			return parent.ArrayInsert(index, current.Clone())
+		}
+
+		_, err = parent.ArrayRemoveAt(index)
+
+		return err
+	case entry.op == ChangeDelete:
+		if reverse {
+			return parent.Set(current.Clone(), last)
+		}
+
+		return parent.Delete(last)
+	default:
+		// при отмене сохранения по отсутствовавшему ключу прежнего объекта нет
+		if current == nil && reverse {
+			return parent.Delete(last)
+		}
+
+		// в родительский объект возвращается копия, объект вызывающего кода не изменяется
+		if parent.IsArray() {
+			index, err := strconv.Atoi(last)
+			if err != nil {
+				return err
+			}
+
+			return parent.ArraySet(index, current.Clone())
+		}
+
+		return parent.Set(current.Clone(), last)
+	}
+}
+
+// Приблизительный размер объекта в памяти в байтах.
+func approximateSize(j *Nested) int {
+	// размер структуры Nested на 64-битной платформе
+	const nodeSize = 64
+
+	if j == nil {
+		return 0
+	}
+
+	size := nodeSize
+
+	switch value := j.value.(type) {
+	case string:
+		size += len(value)
+	case []byte:
+		size += len(value)
+	}
+
+	for _, element := range j.array {
+		size += 8 + approximateSize(element)
+	}
+
+	for k, element := range j.nested {
+		size += 24 + len(k) + approximateSize(element)
+	}
+
+	return size
+}
+
+// Формирование JSON Pointer (RFC 6901) из цепочки ключей.
+func jsonPointer(path []string) string {
+	var builder strings.Builder
+
+	for _, key := range path {
+		builder.WriteString("/")
+		builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1"))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,300 @@
+package nested
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_History(t *testing.T) {
+	nested := FromJSONString(`{"user": {"name": "Alice", "roles": ["admin", "dev"], "age": 30}}`)
+
+	history := NewHistory(nested, 0)
+	defer history.Close()
+
+	assert.False(t, history.CanUndo())
+	assert.EqualError(t, history.Undo(), "nothing to undo")
+	assert.EqualError(t, history.Redo(), "nothing to redo")
+
+	states := []string{nested.ToJSONString()}
+	step := func(err error) {
+		assert.Nil(t, err)
+		states = append(states, nested.ToJSONString())
+	}
+
+	step(nested.SetValue("Bob", "user", "name"))
+	step(nested.SetValue(true, "user", "active"))
+	step(nested.Delete("user", "age"))
+	step(nested.ArrayAddValue("ops", "user", "roles"))
+	step(nested.ArrayDelete(func(element *Nested) bool {
+		value, _ := element.GetValue()
+		return value == "admin"
+	}, "user", "roles"))
+
+	// изменение элемента массива через полученный объект
+	roles, _ := nested.GetArray("user", "roles")
+	step(roles[0].SetValue("developer"))
+
+	assert.Equal(t, `{"user":{"active":true,"name":"Bob","roles":["developer","ops"]}}`, nested.ToJSONString())
+
+	for index := len(states) - 2; index >= 0; index-- {
+		assert.Nil(t, history.Undo())
+		assert.Equal(t, states[index], nested.ToJSONString())
+	}
+
+	assert.False(t, history.CanUndo())
+	assert.True(t, history.CanRedo())
+
+	// удаленный через ArrayDelete элемент восстановлен несмотря на очистку
+	value, err := nested.GetArray("user", "roles")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "admin", value[0].value)
+	}
+
+	for index := 1; index < len(states); index++ {
+		assert.Nil(t, history.Redo())
+		assert.Equal(t, states[index], nested.ToJSONString())
+	}
+
+	hash, err := nested.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, hash, mustHash(t, FromJSONString(states[len(states)-1])))
+
+	assert.Equal(t, `[`+
+		`{"op":"replace","path":"/user/name","value":"Bob"},`+
+		`{"op":"add","path":"/user/active","value":true},`+
+		`{"op":"remove","path":"/user/age"},`+
+		`{"op":"add","path":"/user/roles/2","value":"ops"},`+
+		`{"op":"remove","path":"/user/roles/0"},`+
+		`{"op":"replace","path":"/user/roles/0","value":"developer"}`+
+		`]`, mustJSONPatch(t, history))
+
+	// новое изменение очищает отмененные
+	assert.Nil(t, history.Undo())
+	assert.Nil(t, nested.SetValue(1, "count"))
+	assert.False(t, history.CanRedo())
+
+	// после закрытия изменения не записываются
+	history.Close()
+	assert.Nil(t, nested.SetValue(2, "count"))
+	assert.Nil(t, history.Undo())
+	assert.Equal(t, `{"user":{"active":true,"name":"Bob","roles":["dev","ops"]}}`, nested.ToJSONString())
+}
+
+func Test_HistoryCheckpoints(t *testing.T) {
+	nested := FromJSONString(`{"a": 1}`)
+
+	history := NewHistory(nested, 3)
+
+	history.Checkpoint("start")
+	assert.Nil(t, nested.SetValue(2, "a"))
+	history.Checkpoint("two")
+	assert.Nil(t, nested.SetValue(3, "a"))
+	assert.Nil(t, nested.SetValue(4, "a"))
+	history.Checkpoint("four")
+
+	assert.Equal(t, []string{"start", "two", "four"}, history.Checkpoints())
+
+	assert.Nil(t, history.Restore("two"))
+	assert.Equal(t, `{"a":2}`, nested.ToJSONString())
+
+	assert.Nil(t, history.Restore("four"))
+	assert.Equal(t, `{"a":4}`, nested.ToJSONString())
+
+	assert.Nil(t, history.Restore("start"))
+	assert.Equal(t, `{"a":1}`, nested.ToJSONString())
+
+	assert.EqualError(t, history.Restore("missing"), "checkpoint 'missing' not found")
+
+	// новое изменение делает недостижимыми метки после текущей позиции
+	assert.Nil(t, history.Redo())
+	assert.Nil(t, nested.SetValue(5, "a"))
+	assert.Equal(t, []string{"start", "two"}, history.Checkpoints())
+
+	// вытеснение самой старой записи удаляет метку на ее позиции
+	assert.Nil(t, nested.SetValue(6, "a"))
+	assert.Nil(t, nested.SetValue(7, "a"))
+	assert.Equal(t, []string{"two"}, history.Checkpoints())
+
+	assert.Nil(t, history.Undo())
+	assert.Nil(t, history.Undo())
+	assert.Nil(t, history.Undo())
+	assert.EqualError(t, history.Undo(), "nothing to undo")
+	assert.Equal(t, `{"a":2}`, nested.ToJSONString())
+}
+
+func Test_HistoryInPlace(t *testing.T) {
+	nested := &Nested{}
+
+	events := 0
+	nested.Subscribe(func(event ChangeEvent) {
+		events++
+	})
+
+	history := NewHistory(nested, 0)
+
+	assert.Nil(t, nested.SetMap(map[string]*Nested{"a": {isValue: true, value: 1}}))
+	assert.Nil(t, nested.SetValue(1, "b", "c"))
+
+	a, _ := nested.Get("a")
+	assert.Nil(t, a.SetValue(2))
+
+	assert.Nil(t, history.Undo())
+	assert.Nil(t, history.Undo())
+	assert.Nil(t, history.Undo())
+
+	// отмена замены содержимого исходного объекта возвращает его целиком
+	assert.Equal(t, `{}`, nested.ToJSONString())
+	assert.True(t, nested.IsEmpty())
+
+	assert.Nil(t, history.Redo())
+	assert.Nil(t, history.Redo())
+	assert.Equal(t, `{"a":1,"b":{"c":1}}`, nested.ToJSONString())
+
+	// отмена и повтор доставляются подписчикам
+	assert.Equal(t, 8, events)
+
+	assert.Equal(t, `[{"op":"replace","path":"","value":{"a":1}},{"op":"add","path":"/b","value":{"c":1}}]`, mustJSONPatch(t, history))
+
+	// отмена сохранения по отсутствовавшему ключу удаляет созданные промежуточные объекты
+	assert.Nil(t, nested.SetValue(1, "x", "y", "z"))
+	assert.Nil(t, history.Undo())
+	assert.Equal(t, `{"a":1,"b":{"c":1}}`, nested.ToJSONString())
+
+	assert.Nil(t, history.Redo())
+	assert.Equal(t, `{"a":1,"b":{"c":1},"x":{"y":{"z":1}}}`, nested.ToJSONString())
+	assert.Nil(t, history.Undo())
+
+	// история вложенного объекта
+	b, _ := nested.Get("b")
+	nestedHistory := NewHistory(b, 0)
+
+	assert.Nil(t, nested.SetValue(2, "b", "c"))
+	assert.Nil(t, nested.SetValue(2, "a"))
+	assert.Equal(t, `[{"op":"replace","path":"/c","value":2}]`, mustJSONPatch(t, nestedHistory))
+
+	// отмена возвращает прежний объект в родительский и не изменяет полученный ранее
+	c, _ := nested.Get("b", "c")
+	assert.Nil(t, nestedHistory.Undo())
+	assert.Equal(t, 2, c.value)
+	assert.Equal(t, `{"a":2,"b":{"c":1}}`, nested.ToJSONString())
+
+	// замена вложенного объекта целиком не записывается в его историю
+	assert.Nil(t, nested.SetValue(3, "b"))
+	assert.False(t, nestedHistory.CanUndo())
+}
+
+func Test_HistoryShared(t *testing.T) {
+	root := &Nested{}
+	history := NewHistory(root, 0)
+
+	shared := FromJSONString(`{"x":0}`)
+	assert.Nil(t, root.Set(FromJSONString(`{"x":0}`), "a"))
+	assert.Nil(t, root.SetValue(1, "a", "x"))
+	assert.Nil(t, shared.SetValue(1, "x"))
+
+	// объект вызывающего кода, сохраненный в отслеживаемый, не изменяется отменой
+	assert.Nil(t, root.Set(shared, "a"))
+	assert.Nil(t, history.Undo())
+	assert.Equal(t, `{"x":1}`, shared.ToJSONString())
+
+	a, _ := root.Get("a")
+	assert.NotSame(t, shared, a)
+	assert.Equal(t, `{"a":{"x":1}}`, root.ToJSONString())
+
+	assert.Nil(t, history.Undo())
+	assert.Equal(t, `{"x":1}`, shared.ToJSONString())
+	assert.Equal(t, `{"a":{"x":0}}`, root.ToJSONString())
+
+	// элементы массива возвращаются по индексу
+	assert.Nil(t, root.SetArray([]*Nested{{isValue: true, value: 1}}, "b"))
+	assert.Nil(t, root.ArraySet(0, shared, "b"))
+	assert.Nil(t, history.Undo())
+	assert.Equal(t, `{"a":{"x":0},"b":[1]}`, root.ToJSONString())
+	assert.Equal(t, `{"x":1}`, shared.ToJSONString())
+
+	assert.Nil(t, history.Redo())
+	assert.Equal(t, `{"a":{"x":0},"b":[{"x":1}]}`, root.ToJSONString())
+
+	// очистка отменяется восстановлением прежнего содержимого
+	b, _ := root.Get("b")
+	assert.Nil(t, b.Clear())
+	assert.Nil(t, root.Clear())
+	assert.Equal(t, `{}`, root.ToJSONString())
+
+	assert.Nil(t, history.Undo())
+	assert.Equal(t, `{"a":{"x":0},"b":{}}`, root.ToJSONString())
+	assert.Nil(t, history.Undo())
+	assert.Equal(t, `{"a":{"x":0},"b":[{"x":1}]}`, root.ToJSONString())
+	assert.Equal(t, `{"x":1}`, shared.ToJSONString())
+}
+
+func Test_JSONPointer(t *testing.T) {
+	assert.Equal(t, "", jsonPointer(nil))
+	assert.Equal(t, "/a~1b/m~0n/0", jsonPointer([]string{"a/b", "m~n", "0"}))
+}
+
+func Test_HistoryMaxSize(t *testing.T) {
+	nested := &Nested{}
+
+	history := NewHistory(nested, 0)
+	defer history.Close()
+
+	history.Checkpoint("empty")
+
+	assert.Nil(t, nested.SetValue(strings.Repeat("a", 1000), "a"))
+	assert.Nil(t, nested.SetValue(strings.Repeat("b", 1000), "b"))
+	assert.Nil(t, nested.SetValue(strings.Repeat("c", 1000), "c"))
+
+	// ограничение вытесняет самые старые записи
+	history.SetMaxSize(2500)
+	assert.Equal(t, []string{}, history.Checkpoints())
+
+	assert.Nil(t, history.Undo())
+	assert.Nil(t, history.Undo())
+	assert.EqualError(t, history.Undo(), "nothing to undo")
+	assert.Equal(t, `{"a":"`+strings.Repeat("a", 1000)+`"}`, nested.ToJSONString())
+
+	// новое изменение освобождает место отмененных записей
+	assert.Nil(t, nested.SetValue(strings.Repeat("d", 1000), "d"))
+	assert.Nil(t, nested.SetValue(strings.Repeat("e", 1000), "e"))
+	assert.True(t, history.CanUndo())
+	assert.Nil(t, history.Undo())
+	assert.Nil(t, history.Undo())
+	assert.False(t, history.CanUndo())
+
+	// запись больше ограничения не сохраняется
+	assert.Nil(t, nested.SetValue(strings.Repeat("f", 3000), "f"))
+	assert.False(t, history.CanUndo())
+	assert.False(t, history.CanRedo())
+}
+
+func Test_HistoryJSONPatchError(t *testing.T) {
+	nested := &Nested{}
+
+	history := NewHistory(nested, 0)
+	defer history.Close()
+
+	assert.Nil(t, nested.SetValue(math.NaN(), "a"))
+
+	_, err := history.ToJSONPatch()
+	assert.NotNil(t, err)
+}
+
+// Конвертация истории в JSON Patch с проверкой ошибки.
+func mustJSONPatch(t *testing.T, history *History) string {
+	patch, err := history.ToJSONPatch()
+	assert.Nil(t, err)
+
+	return string(patch)
+}
+
+// Вычисление хеша с проверкой ошибки.
+func mustHash(t *testing.T, nested *Nested) [HashSize]byte {
+	hash, err := nested.Hash()
+	assert.Nil(t, err)
+
+	return hash
+}
@@ -0,0 +1,285 @@
+package nested
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Индекс элементов массива по значению по цепочке ключей.
+//
+// Позволяет находить элементы массива по значению (например, по "id") за O(1) вместо перебора
+// через [Nested.ArrayFindOne]. Значения сравниваются по содержимому так же, как в [Nested.Hash],
+// поэтому, например, 1 и 1.0 совпадают, а 1 и "1" - нет, и целые числа вне диапазона точного
+// представления в float64 сравниваются без потери точности. Элементы без значения по цепочке ключей
+// и со значениями, для которых хеш вычислить нельзя (NaN, бесконечности), не индексируются.
+// Элементы с одинаковыми значениями возвращаются в порядке их расположения в массиве.
+//
+// Индекс использует режим отслеживания изменений (см. [Nested.Subscribe]) и обновляется при изменениях
+// массива через любые функции (ArrayAdd, ArrayDelete, ArrayInsert и другие), в том числе при изменении
+// значений внутри элементов. При замене массива целиком индекс перестраивается при следующем обращении.
+// Один и тот же указатель не должен храниться в массиве несколько раз.
+//
+// Не является конкурентно-безопасным.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"users": [{"id": 1, "name": "Alice"}, {"id": 2, "name": "Bob"}]}`)
+//
+//	index, _ := nested.ArrayIndex([]string{"id"}, "users")
+//	defer index.Close()
+//
+//	nested.ArrayAdd(FromJSONString(`{"id": 3, "name": "Carol"}`), "users")
+//
+//	user, _ := index.Get(3) // {"id": 3, "name": "Carol"}
+type Index struct {
+	root        *Nested
+	keys        []string // цепочка ключей от root до массива
+	path        []string // путь массива от корня, для которого включен режим отслеживания
+	by          []string
+	array       *Nested
+	elements    map[string][]*Nested // элементы для каждого значения
+	values      map[*Nested]string   // значение для каждого проиндексированного элемента
+	positions   map[*Nested]int      // позиции элементов в массиве, вычисляются при обращении
+	dirty       bool
+	unsubscribe func()
+}
+
+// Создание индекса элементов массива по цепочке ключей по значениям по цепочке by.
+//
+// Пустая цепочка by индексирует элементы по их собственным значениям.
+// Включает режим отслеживания изменений объекта (см. [Nested.Observe]).
+func (j *Nested) ArrayIndex(by []string, keys ...string) (*Index, error) {
+	if _, err := j.getArrayNode(keys...); err != nil {
+		return nil, err
+	}
+
+	index := &Index{
+		root: j,
+		keys: slices.Clone(keys),
+		by:   slices.Clone(by),
+	}
+
+	index.unsubscribe = j.Subscribe(index.update, keys...)
+	index.path = append(j.observedPath(), keys...)
+	index.rebuild()
+
+	return index, nil
+}
+
+// Прекращение обновления индекса.
+func (i *Index) Close() {
+	i.unsubscribe()
+}
+
+// Получение первого в порядке массива элемента со значением value.
+//
+// value - скалярное значение или *Nested.
+func (i *Index) Get(value any) (*Nested, bool) {
+	elements := i.GetAll(value)
+	if len(elements) == 0 {
+		return nil, false
+	}
+
+	return elements[0], true
+}
+
+// Получение всех элементов со значением value в порядке массива.
+//
+// value - скалярное значение или *Nested.
+func (i *Index) GetAll(value any) []*Nested {
+	i.refresh()
+
+	key, ok := indexKey(indexValue(value))
+	if !ok {
+		return nil
+	}
+
+	elements := slices.Clone(i.elements[key])
+
+	if len(elements) > 1 {
+		positions := i.elementPositions()
+		slices.SortFunc(elements, func(a, b *Nested) int {
+			return positions[a] - positions[b]
+		})
+	}
+
+	return elements
+}
+
+// Проверка наличия элемента со значением value.
+func (i *Index) Contains(value any) bool {
+	_, ok := i.Get(value)
+	return ok
+}
+
+// Количество различных проиндексированных значений.
+func (i *Index) Len() int {
+	i.refresh()
+
+	return len(i.elements)
+}
+
+// Проверка уникальности значений.
+//
+// Возвращает ошибку с первым в алфавитном порядке повторяющимся значением в виде JSON.
+func (i *Index) Unique() error {
+	i.refresh()
+
+	for _, key := range sortedKeys(i.elements) {
+		if count := len(i.elements[key]); count > 1 {
+			return fmt.Errorf("value %s is not unique: %d elements", key, count)
+		}
+	}
+
+	return nil
+}
+
+// Перестроение индекса, если массив был заменен.
+func (i *Index) refresh() {
+	if i.dirty {
+		i.rebuild()
+	}
+}
+
+// Позиции элементов в текущем массиве.
+func (i *Index) elementPositions() map[*Nested]int {
+	if i.positions != nil {
+		return i.positions
+	}
+
+	i.positions = map[*Nested]int{}
+
+	if i.array != nil {
+		for position, element := range i.array.array {
+			i.positions[element] = position
+		}
+	}
+
+	return i.positions
+}
+
+// Построение индекса по текущему массиву.
+func (i *Index) rebuild() {
+	i.dirty = false
+	i.positions = nil
+	i.elements = map[string][]*Nested{}
+	i.values = map[*Nested]string{}
+
+	array, err := i.root.getArrayNode(i.keys...)
+	if err != nil {
+		i.array = nil
+		return
+	}
+
+	i.array = array
+
+	for _, element := range array.array {
+		i.add(element)
+	}
+}
+
+// Обновление индекса по событию изменения.
+func (i *Index) update(event ChangeEvent) {
+	i.positions = nil
+
+	if i.dirty {
+		return
+	}
+
+	if len(event.Path) <= len(i.path) {
+		// замена массива, его содержимого или родительского объекта
+		i.dirty = true
+		return
+	}
+
+	relative := event.Path[len(i.path):]
+
+	if len(relative) == 1 {
+		switch event.Op {
+		case ChangeAdd:
+			i.add(event.New)
+		case ChangeRemove:
+			i.remove(event.Old)
+		default:
+			// при замене содержимого на месте New - тот же элемент, что уже в индексе
+			i.remove(event.Old)
+			i.remove(event.New)
+			i.add(event.New)
+		}
+
+		return
+	}
+
+	// изменение внутри элемента
+	position, err := strconv.Atoi(relative[0])
+	if err != nil || i.array == nil || position >= len(i.array.array) {
+		i.dirty = true
+		return
+	}
+
+	element := i.array.array[position]
+	i.remove(element)
+	i.add(element)
+}
+
+// Добавление элемента в индекс.
+func (i *Index) add(element *Nested) {
+	if element == nil {
+		return
+	}
+
+	key, ok := indexKey(elementKey(element, i.by))
+	if !ok {
+		return
+	}
+
+	i.elements[key] = append(i.elements[key], element)
+	i.values[element] = key
+}
+
+// Удаление элемента из индекса.
+func (i *Index) remove(element *Nested) {
+	key, ok := i.values[element]
+	if !ok {
+		return
+	}
+
+	delete(i.values, element)
+
+	elements := slices.DeleteFunc(i.elements[key], func(e *Nested) bool {
+		return e == element
+	})
+
+	if len(elements) == 0 {
+		delete(i.elements, key)
+	} else {
+		i.elements[key] = elements
+	}
+}
+
+// Значение для поиска в индексе.
+func indexValue(value any) *Nested {
+	if nested, ok := value.(*Nested); ok {
+		return nested
+	}
+
+	return &Nested{isValue: true, value: value}
+}
+
+// Ключ индекса для значения в виде канонического JSON.
+//
+// Скалярные значения записываются так же, как для хеширования, поэтому большие целые числа не теряют точность.
+func indexKey(value *Nested) (string, bool) {
+	if value == nil {
+		return "", false
+	}
+
+	var buffer bytes.Buffer
+	if err := writeCanonicalNestedWith(&buffer, value, writeHashValue); err != nil {
+		return "", false
+	}
+
+	return buffer.String(), true
+}
@@ -0,0 +1,189 @@
+package nested
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ArrayIndex(t *testing.T) {
+	nested := FromJSONString(`{"data": {"users": [
+		{"id": 1, "name": "Alice"},
+		{"id": 2, "name": "Bob"},
+		{"name": "anonymous"}
+	]}}`)
+
+	index, err := nested.ArrayIndex([]string{"id"}, "data", "users")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	defer index.Close()
+
+	name := func(value any) any {
+		element, ok := index.Get(value)
+		if !ok {
+			return nil
+		}
+
+		result, _ := element.GetValue("name")
+		return result
+	}
+
+	assert.Equal(t, "Alice", name(1))
+	assert.Equal(t, "Alice", name(1.0))
+	assert.Nil(t, name("1"))
+	assert.Equal(t, 2, index.Len())
+	assert.Nil(t, index.Unique())
+
+	// добавление и удаление через исходный объект
+	assert.Nil(t, nested.ArrayAdd(FromJSONString(`{"id": 3, "name": "Carol"}`), "data", "users"))
+	assert.Nil(t, nested.ArrayDelete(func(element *Nested) bool {
+		id, _ := element.GetValue("id")
+		return id == 1
+	}, "data", "users"))
+
+	assert.Equal(t, "Carol", name(3))
+	assert.Nil(t, name(1))
+	assert.False(t, index.Contains(1))
+
+	// изменения через вложенные объекты
+	users, _ := nested.Get("data", "users")
+	assert.Nil(t, users.ArrayInsert(0, FromJSONString(`{"id": 2, "name": "Bob 2"}`)))
+	assert.Len(t, index.GetAll(2), 2)
+	assert.EqualError(t, index.Unique(), "value 2 is not unique: 2 elements")
+
+	bob, _ := users.ArrayGet(1)
+	assert.Nil(t, bob.SetValue(4, "id"))
+	assert.Equal(t, "Bob", name(4))
+	assert.Equal(t, "Bob 2", name(2))
+	assert.Nil(t, index.Unique())
+
+	removed, err := users.ArrayRemoveAt(-1)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "Carol", removed.nested["name"].value)
+	}
+
+	assert.False(t, index.Contains(3))
+
+	assert.Nil(t, users.ArraySet(0, FromJSONString(`{"id": 5, "name": "Eve"}`)))
+	assert.Equal(t, "Eve", name(5))
+	assert.False(t, index.Contains(2))
+
+	// замена массива целиком перестраивает индекс
+	assert.Nil(t, nested.Set(FromJSONString(`[{"id": 7, "name": "Gus"}]`), "data", "users"))
+	assert.Equal(t, "Gus", name(7))
+	assert.Equal(t, 1, index.Len())
+
+	assert.Nil(t, nested.ArrayAdd(FromJSONString(`{"id": 8, "name": "Hal"}`), "data", "users"))
+	assert.Equal(t, "Hal", name(8))
+
+	// очистка массива через вложенный объект
+	users, _ = nested.Get("data", "users")
+	assert.Nil(t, users.Clear())
+	assert.Equal(t, 0, index.Len())
+	assert.False(t, index.Contains(8))
+
+	// удаление массива оставляет индекс пустым
+	assert.Nil(t, nested.Delete("data", "users"))
+	assert.Equal(t, 0, index.Len())
+
+	// после закрытия индекс не обновляется
+	assert.Nil(t, nested.SetArray([]*Nested{FromJSONString(`{"id": 9}`)}, "data", "users"))
+	assert.Equal(t, 1, index.Len())
+	index.Close()
+
+	assert.Nil(t, nested.ArrayAdd(FromJSONString(`{"id": 10}`), "data", "users"))
+	assert.False(t, index.Contains(10))
+}
+
+func Test_ArrayIndexValues(t *testing.T) {
+	nested := FromJSONString(`["a", "b", ["x"], "a"]`)
+
+	index, err := nested.ArrayIndex(nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Len(t, index.GetAll("a"), 2)
+	assert.True(t, index.Contains(FromJSONString(`["x"]`)))
+	assert.EqualError(t, index.Unique(), `value "a" is not unique: 2 elements`)
+
+	first, _ := nested.ArrayGet(0)
+	assert.Nil(t, first.SetValue("c"))
+	assert.Len(t, index.GetAll("a"), 1)
+	assert.True(t, index.Contains("c"))
+
+	assert.Nil(t, nested.ArraySortBy(nil))
+	assert.Equal(t, 4, index.Len())
+
+	_, err = nested.ArrayIndex(nil, "a")
+	assert.EqualError(t, err, "is array")
+
+	_, err = FromJSONString(`{"a": 1}`).ArrayIndex(nil, "a")
+	assert.EqualError(t, err, "a: is value")
+}
+
+func Test_ArrayIndexLargeIntegers(t *testing.T) {
+	nested := &Nested{}
+	assert.Nil(t, nested.SetArray([]*Nested{
+		FromObject(map[string]any{"id": int64(1234567890123456789)}),
+		FromObject(map[string]any{"id": int64(1234567890123456788)}),
+	}, "users"))
+
+	index, err := nested.ArrayIndex([]string{"id"}, "users")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	defer index.Close()
+
+	assert.Equal(t, 2, index.Len())
+	assert.True(t, index.Contains(int64(1234567890123456789)))
+	assert.True(t, index.Contains(uint64(1234567890123456788)))
+	assert.False(t, index.Contains(int64(1234567890123456787)))
+	assert.Nil(t, index.Unique())
+
+	assert.Nil(t, nested.ArrayAdd(FromObject(map[string]any{"id": uint64(1234567890123456789)}), "users"))
+	assert.EqualError(t, index.Unique(), "value 1234567890123456789 is not unique: 2 elements")
+}
+
+func Test_ArrayIndexOrder(t *testing.T) {
+	nested := FromJSONString(`[{"id": 1, "v": "a"}, {"id": 1, "v": "b"}]`)
+
+	index, err := nested.ArrayIndex([]string{"id"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	defer index.Close()
+
+	values := func() []any {
+		result := []any{}
+		for _, element := range index.GetAll(1) {
+			value, _ := element.GetValue("v")
+			result = append(result, value)
+		}
+
+		return result
+	}
+
+	// элементы с одинаковыми значениями возвращаются в порядке массива
+	assert.Nil(t, nested.ArrayInsert(0, FromJSONString(`{"id": 1, "v": "c"}`)))
+	assert.Equal(t, []any{"c", "a", "b"}, values())
+
+	first, _ := index.Get(1)
+	assert.Equal(t, "c", first.nested["v"].value)
+
+	assert.Nil(t, nested.ArrayMove(0, -1))
+	assert.Equal(t, []any{"a", "b", "c"}, values())
+
+	// после перестроения порядок тот же
+	assert.Nil(t, nested.ArraySort(func(a, b *Nested) bool {
+		return a.nested["v"].value.(string) > b.nested["v"].value.(string)
+	}))
+	assert.Equal(t, []any{"c", "b", "a"}, values())
+
+	assert.Nil(t, nested.ArrayInsert(1, FromJSONString(`{"id": 1, "v": "d"}`)))
+	assert.Equal(t, []any{"c", "d", "b", "a"}, values())
+}
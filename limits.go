@@ -0,0 +1,294 @@
+package nested
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Ограничения при разборе JSON из недоверенных источников.
+//
+// Нулевое значение поля означает отсутствие ограничения.
+type ParseOptions struct {
+	MaxSize         int64 // максимальный размер входных данных в байтах
+	MaxDepth        int   // максимальная глубина вложенности массивов и словарей
+	MaxNodes        int   // максимальное общее количество объектов (значений, массивов и словарей)
+	MaxStringLength int   // максимальная длина строки (значения или ключа) в байтах после разбора
+	MaxArrayLength  int   // максимальное количество элементов одного массива
+	MaxKeys         int   // максимальное количество ключей одного словаря
+}
+
+// Названия ограничений для [LimitError].
+const (
+	LimitSize         = "size"
+	LimitDepth        = "depth"
+	LimitNodes        = "nodes"
+	LimitStringLength = "string length"
+	LimitArrayLength  = "array length"
+	LimitKeys         = "keys"
+)
+
+// Ошибка превышения одного из ограничений [ParseOptions].
+type LimitError struct {
+	Limit  string // название ограничения, одна из констант Limit*
+	Max    int64  // значение ограничения
+	Offset int64  // смещение во входных данных, на котором было обнаружено превышение
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit %d exceeded at offset %d", e.Limit, e.Max, e.Offset)
+}
+
+// Создание объекта из JSON с проверкой ограничений.
+//
+// В отличие от [FromJSONString], функция требует корректный JSON-документ и возвращает ошибку разбора,
+// а не объект-строку. Значения конвертируются по тем же правилам, что и в [FromObject].
+//
+// Данные разбираются потоково, и при превышении ограничения разбор прекращается с ошибкой [LimitError]
+// до того, как весь документ будет прочитан в память.
+//
+// Пример:
+//
+//	nested, err := ParseJSON(request.Body, ParseOptions{
+//		MaxSize:  1 << 20,
+//		MaxDepth: 32,
+//		MaxNodes: 10000,
+//	})
+//
+//	var limitErr *LimitError
+//	if errors.As(err, &limitErr) {
+//		// слишком большой или сложный документ
+//	}
+func ParseJSON(r io.Reader, options ParseOptions) (*Nested, error) {
+	if options.MaxSize > 0 {
+		r = &limitedReader{reader: r, max: options.MaxSize}
+	}
+
+	if options.MaxStringLength > 0 {
+		r = &stringLimitReader{reader: r, max: options.MaxStringLength}
+	}
+
+	parser := limitedParser{
+		decoder: json.NewDecoder(r),
+		options: options,
+	}
+
+	nested, err := parser.parse(0)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := parser.decoder.Token(); err != io.EOF {
+		if err == nil {
+			return nil, fmt.Errorf("unexpected data after top-level value at offset %d", parser.decoder.InputOffset())
+		}
+
+		return nil, err
+	}
+
+	return nested, nil
+}
+
+// Создание объекта из JSON-строки с проверкой ограничений.
+//
+// См. [ParseJSON].
+func ParseJSONString(nested string, options ParseOptions) (*Nested, error) {
+	return ParseJSON(strings.NewReader(nested), options)
+}
+
+// Потоковый парсер с подсчетом ограничений.
+type limitedParser struct {
+	decoder *json.Decoder
+	options ParseOptions
+
+	nodes int // количество уже созданных объектов
+}
+
+// Рекурсивный разбор очередного значения.
+func (p *limitedParser) parse(depth int) (*Nested, error) {
+	token, err := p.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	p.nodes++
+	if p.options.MaxNodes > 0 && p.nodes > p.options.MaxNodes {
+		return nil, p.limitError(LimitNodes, p.options.MaxNodes)
+	}
+
+	switch token {
+	case json.Delim('{'):
+		if err := p.checkDepth(depth + 1); err != nil {
+			return nil, err
+		}
+
+		nested := map[string]*Nested{}
+
+		for p.decoder.More() {
+			key, err := p.decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			if err := p.checkString(key.(string)); err != nil {
+				return nil, err
+			}
+
+			if _, ok := nested[key.(string)]; !ok && p.options.MaxKeys > 0 && len(nested) >= p.options.MaxKeys {
+				return nil, p.limitError(LimitKeys, p.options.MaxKeys)
+			}
+
+			value, err := p.parse(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			nested[key.(string)] = value
+		}
+
+		if _, err := p.decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		return &Nested{nested: nested}, nil
+	case json.Delim('['):
+		if err := p.checkDepth(depth + 1); err != nil {
+			return nil, err
+		}
+
+		array := []*Nested{}
+
+		for p.decoder.More() {
+			if p.options.MaxArrayLength > 0 && len(array) >= p.options.MaxArrayLength {
+				return nil, p.limitError(LimitArrayLength, p.options.MaxArrayLength)
+			}
+
+			element, err := p.parse(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, element)
+		}
+
+		if _, err := p.decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		return &Nested{isArray: true, array: array}, nil
+	}
+
+	if value, ok := token.(string); ok {
+		if err := p.checkString(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return FromObject(token), nil
+}
+
+// Проверка глубины вложенности.
+func (p *limitedParser) checkDepth(depth int) error {
+	if p.options.MaxDepth > 0 && depth > p.options.MaxDepth {
+		return p.limitError(LimitDepth, p.options.MaxDepth)
+	}
+
+	return nil
+}
+
+// Проверка длины строки.
+func (p *limitedParser) checkString(s string) error {
+	if p.options.MaxStringLength > 0 && len(s) > p.options.MaxStringLength {
+		return p.limitError(LimitStringLength, p.options.MaxStringLength)
+	}
+
+	return nil
+}
+
+// Создание ошибки превышения ограничения с текущим смещением.
+func (p *limitedParser) limitError(limit string, max int) *LimitError {
+	return &LimitError{
+		Limit:  limit,
+		Max:    int64(max),
+		Offset: p.decoder.InputOffset(),
+	}
+}
+
+// Reader, возвращающий [LimitError] при превышении размера входных данных.
+type limitedReader struct {
+	reader io.Reader
+	max    int64
+	read   int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.read > r.max {
+		return 0, &LimitError{Limit: LimitSize, Max: r.max, Offset: r.max}
+	}
+
+	// читается на один байт больше ограничения, чтобы отличить данные ровно максимального размера
+	if int64(len(p)) > r.max-r.read+1 {
+		p = p[:r.max-r.read+1]
+	}
+
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	if r.read > r.max {
+		return 0, &LimitError{Limit: LimitSize, Max: r.max, Offset: r.max}
+	}
+
+	return n, err
+}
+
+// Максимальное количество байт во входных данных на один байт разобранной строки (\uXXXX).
+const maxEscapedByteSize = 6
+
+// Reader, возвращающий [LimitError] при чтении строки, которая заведомо длиннее ограничения.
+//
+// Декодер JSON накапливает строку целиком, прежде чем вернуть ее, поэтому длина проверяется
+// по входным данным во время чтения. Строка длиннее maxEscapedByteSize*max байт во входных данных
+// заведомо превышает ограничение, так что в памяти не накапливается больше. Точная длина разобранной
+// строки проверяется после ее чтения.
+type stringLimitReader struct {
+	reader io.Reader
+	max    int
+
+	offset   int64 // смещение начала очередного блока во входных данных
+	inString bool  // чтение внутри строки
+	escaped  bool  // предыдущий байт - "\\" внутри строки
+	length   int   // количество байт текущей строки во входных данных
+}
+
+func (r *stringLimitReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+
+	for index, b := range p[:n] {
+		switch {
+		case !r.inString:
+			r.inString, r.length = b == '"', 0
+			continue
+		case r.escaped:
+			r.escaped = false
+		case b == '\\':
+			r.escaped = true
+		case b == '"':
+			r.inString = false
+			continue
+		}
+
+		r.length++
+		if r.length > maxEscapedByteSize*r.max {
+			return 0, &LimitError{Limit: LimitStringLength, Max: int64(r.max), Offset: r.offset + int64(index)}
+		}
+	}
+
+	r.offset += int64(n)
+
+	return n, err
+}
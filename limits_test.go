@@ -0,0 +1,121 @@
+package nested
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseJSON(t *testing.T) {
+	nested, err := ParseJSONString(`{"str": "string", "number": 42, "float": 42.5, "array": [true, null, {}]}`, ParseOptions{})
+	if assert.Nil(t, err) {
+		assert.Equal(t,
+			FromJSONString(`{"str": "string", "number": 42, "float": 42.5, "array": [true, null, {}]}`),
+			nested,
+		)
+	}
+
+	nested, err = ParseJSONString(`"string"`, ParseOptions{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{isValue: true, value: "string"}, nested)
+	}
+
+	nested, err = ParseJSONString(`[]`, ParseOptions{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{isArray: true, array: []*Nested{}}, nested)
+	}
+
+	_, err = ParseJSONString(``, ParseOptions{})
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	_, err = ParseJSONString(`[[4, 5]`, ParseOptions{})
+	assert.EqualError(t, err, "unexpected end of JSON input")
+
+	_, err = ParseJSONString(`string`, ParseOptions{})
+	assert.EqualError(t, err, "invalid character 's' looking for beginning of value")
+
+	_, err = ParseJSONString(`{} {}`, ParseOptions{})
+	assert.EqualError(t, err, "unexpected data after top-level value at offset 4")
+
+	input := `{"a": [1, 2, {"b": "string"}], "c": {"d": {}}}`
+
+	cases := []struct {
+		options ParseOptions
+		err     string
+	}{
+		{ParseOptions{MaxSize: int64(len(input))}, ""},
+		{ParseOptions{MaxSize: 20}, "size limit 20 exceeded at offset 20"},
+		{ParseOptions{MaxDepth: 3}, ""},
+		{ParseOptions{MaxDepth: 2}, "depth limit 2 exceeded at offset 14"},
+		{ParseOptions{MaxNodes: 11}, ""},
+		{ParseOptions{MaxNodes: 5}, "nodes limit 5 exceeded at offset 27"},
+		{ParseOptions{MaxStringLength: 6}, ""},
+		{ParseOptions{MaxStringLength: 5}, "string length limit 5 exceeded at offset 27"},
+		{ParseOptions{MaxArrayLength: 3}, ""},
+		{ParseOptions{MaxArrayLength: 2}, "array length limit 2 exceeded at offset 11"},
+		{ParseOptions{MaxKeys: 2}, ""},
+		{ParseOptions{MaxKeys: 1}, "keys limit 1 exceeded at offset 34"},
+	}
+
+	for _, c := range cases {
+		nested, err := ParseJSONString(input, c.options)
+
+		if c.err == "" {
+			if assert.Nil(t, err) {
+				assert.Equal(t, FromJSONString(input), nested)
+			}
+			continue
+		}
+
+		var limitErr *LimitError
+		if assert.True(t, errors.As(err, &limitErr), c.err) {
+			assert.EqualError(t, err, c.err)
+		}
+	}
+
+	_, err = ParseJSONString(`{"a": 1, "a": 2}`, ParseOptions{MaxKeys: 1})
+	assert.Nil(t, err)
+}
+
+// Reader с бесконечной последовательностью одного байта.
+type repeatReader struct {
+	b    byte
+	read int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	for index := range p {
+		p[index] = r.b
+	}
+
+	r.read += len(p)
+
+	return len(p), nil
+}
+
+func Test_ParseJSONStringLength(t *testing.T) {
+	// бесконечная строка прерывается до того, как будет прочитана в память
+	endless := &repeatReader{b: 'x'}
+
+	_, err := ParseJSON(io.MultiReader(strings.NewReader(`{"a": "`), endless), ParseOptions{MaxStringLength: 10})
+
+	var limitErr *LimitError
+	if assert.True(t, errors.As(err, &limitErr)) {
+		assert.Equal(t, LimitStringLength, limitErr.Limit)
+		assert.Equal(t, int64(7+maxEscapedByteSize*10), limitErr.Offset)
+	}
+
+	assert.Less(t, endless.read, 1<<20)
+
+	// экранированные символы учитываются по длине после разбора
+	nested, err := ParseJSONString(`{"AB": "ABC\"\\"}`, ParseOptions{MaxStringLength: 5})
+	if assert.Nil(t, err) {
+		assert.Equal(t, FromJSONString(`{"AB": "ABC\"\\"}`), nested)
+	}
+
+	_, err = ParseJSONString(`["ABCDEF"]`, ParseOptions{MaxStringLength: 5})
+	assert.EqualError(t, err, "string length limit 5 exceeded at offset 9")
+}
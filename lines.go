@@ -0,0 +1,256 @@
+package nested
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Максимальный размер строки по умолчанию для [LinesReader] в байтах.
+const DefaultMaxLineSize = 1 << 20
+
+// Количество первых ошибок пропущенных строк, сохраняемых для [LinesReader.Errors].
+const maxLineErrors = 100
+
+// Ошибка [LinesReader] для строки длиннее ограничения, см. [LinesReader.SetMaxLineSize].
+var ErrLineTooLong = errors.New("line is too long")
+
+// Ошибка разбора строки в формате JSON Lines (NDJSON).
+type LineError struct {
+	Line int   // номер строки, начиная с 1
+	Err  error // исходная ошибка разбора
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err.Error())
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Построчное чтение объектов Nested из потока в формате JSON Lines (NDJSON).
+//
+// Каждая непустая строка потока должна быть отдельным JSON-документом.
+// Пустые строки и строки из одних пробельных символов пропускаются.
+// В памяти одновременно хранится только текущая строка, поэтому чтение подходит для файлов любого размера.
+// Размер строки ограничен (по умолчанию [DefaultMaxLineSize]), для более длинных строк возвращается
+// [LineError] с ошибкой [ErrLineTooLong], а сама строка не сохраняется в памяти.
+//
+// Значения конвертируются по тем же правилам, что и в [FromJSONString],
+// но некорректный JSON не превращается в строковое значение, а возвращается как ошибка [LineError].
+//
+// По умолчанию чтение останавливается на первой ошибке разбора.
+// С помощью [LinesReader.SetContinueOnError] можно пропускать некорректные строки, ошибки по ним
+// передаются обработчику [LinesReader.SetErrorHandler] или сохраняются для [LinesReader.Errors].
+//
+// Пример:
+//
+//	reader := NewLinesReader(file)
+//	for {
+//		nested, err := reader.Read()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			return err
+//		}
+//
+//		nested.GetValue("level")
+//	}
+type LinesReader struct {
+	reader *bufio.Reader
+	buffer []byte // данные текущей строки
+
+	line        int   // номер последней прочитанной строки
+	err         error // ошибка, после которой чтение невозможно
+	maxLineSize int
+
+	continueOnError bool
+	errorHandler    func(err *LineError)
+	errors          []*LineError // первые ошибки пропущенных строк
+}
+
+// Создание построчного читателя из r.
+func NewLinesReader(r io.Reader) *LinesReader {
+	return &LinesReader{reader: bufio.NewReader(r), maxLineSize: DefaultMaxLineSize}
+}
+
+// Включение или отключение пропуска строк с ошибками разбора.
+func (r *LinesReader) SetContinueOnError(on bool) {
+	r.continueOnError = on
+}
+
+// Установка максимального размера строки в байтах без учета перевода строки, 0 - без ограничения.
+func (r *LinesReader) SetMaxLineSize(size int) {
+	r.maxLineSize = size
+}
+
+// Установка обработчика ошибок строк, пропущенных в режиме [LinesReader.SetContinueOnError].
+//
+// Если обработчик задан, ошибки передаются ему и не сохраняются для [LinesReader.Errors].
+func (r *LinesReader) SetErrorHandler(handler func(err *LineError)) {
+	r.errorHandler = handler
+}
+
+// Номер последней прочитанной строки, начиная с 1.
+func (r *LinesReader) Line() int {
+	return r.line
+}
+
+// Ошибки разбора строк, пропущенных в режиме [LinesReader.SetContinueOnError].
+//
+// Сохраняются только первые 100 ошибок, чтобы память не росла на файлах с большим количеством
+// некорректных строк. Для обработки всех ошибок используется [LinesReader.SetErrorHandler].
+func (r *LinesReader) Errors() []*LineError {
+	return r.errors
+}
+
+// Чтение следующего объекта.
+//
+// По окончании потока возвращает io.EOF.
+// При ошибке разбора возвращает [LineError], и все последующие вызовы вернут ту же ошибку,
+// если не включен пропуск строк с ошибками.
+func (r *LinesReader) Read() (*Nested, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	for {
+		data, tooLong, err := r.readLine()
+		if err != nil && err != io.EOF {
+			r.err = err
+			return nil, err
+		}
+
+		if len(data) == 0 && !tooLong && err == io.EOF {
+			r.err = io.EOF
+			return nil, io.EOF
+		}
+
+		r.line++
+
+		var parseErr error
+		if tooLong {
+			parseErr = ErrLineTooLong
+		} else if data = bytes.TrimSpace(data); len(data) == 0 {
+			continue
+		}
+
+		var obj any
+		if parseErr == nil {
+			parseErr = json.Unmarshal(data, &obj)
+		}
+
+		if parseErr != nil {
+			lineErr := &LineError{Line: r.line, Err: parseErr}
+
+			if r.continueOnError {
+				r.skip(lineErr)
+				continue
+			}
+
+			r.err = lineErr
+			return nil, lineErr
+		}
+
+		return FromObject(obj), nil
+	}
+}
+
+// Чтение очередной строки вместе с переводом строки.
+//
+// Если строка длиннее ограничения, ее данные не сохраняются, а остаток строки пропускается.
+func (r *LinesReader) readLine() ([]byte, bool, error) {
+	r.buffer = r.buffer[:0]
+	tooLong := false
+
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+
+		size := len(r.buffer) + len(chunk)
+		if err == nil {
+			size-- // перевод строки
+		}
+
+		if !tooLong && r.maxLineSize > 0 && size > r.maxLineSize {
+			tooLong = true
+			r.buffer = r.buffer[:0]
+		}
+
+		if !tooLong {
+			r.buffer = append(r.buffer, chunk...)
+		}
+
+		if err != bufio.ErrBufferFull {
+			return r.buffer, tooLong, err
+		}
+	}
+}
+
+// Учет ошибки пропущенной строки.
+func (r *LinesReader) skip(err *LineError) {
+	if r.errorHandler != nil {
+		r.errorHandler(err)
+		return
+	}
+
+	if len(r.errors) < maxLineErrors {
+		r.errors = append(r.errors, err)
+	}
+}
+
+// Построчная запись объектов Nested в поток в формате JSON Lines (NDJSON).
+//
+// Каждый объект записывается в одну строку в компактном виде с сортировкой ключей,
+// как в [Nested.ToJSONString]. Строковые значения верхнего уровня записываются в кавычках.
+//
+// Запись буферизуется, по окончании работы необходимо вызвать [LinesWriter.Flush].
+//
+// Пример:
+//
+//	writer := NewLinesWriter(file)
+//	for _, nested := range records {
+//		if err := writer.Write(nested); err != nil {
+//			return err
+//		}
+//	}
+//
+//	return writer.Flush()
+type LinesWriter struct {
+	writer *bufio.Writer
+
+	line    bytes.Buffer // буфер текущей строки
+	encoder *Encoder
+}
+
+// Создание построчного писателя в w.
+func NewLinesWriter(w io.Writer) *LinesWriter {
+	writer := &LinesWriter{writer: bufio.NewWriter(w)}
+	writer.encoder = NewEncoder(&writer.line)
+
+	return writer
+}
+
+// Запись объекта в отдельную строку.
+//
+// Если объект не может быть сконвертирован в JSON, возвращается ошибка, и в поток ничего не записывается.
+func (w *LinesWriter) Write(j *Nested) error {
+	w.line.Reset()
+
+	if err := w.encoder.Encode(j); err != nil {
+		return err
+	}
+
+	_, err := w.writer.Write(w.line.Bytes())
+
+	return err
+}
+
+// Запись буферизованных данных в исходный поток.
+func (w *LinesWriter) Flush() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,169 @@
+package nested
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LinesReader(t *testing.T) {
+	input := "{\"a\": 1}\n\n  \r\n[1, 2]\r\n\"string\"\n42.5"
+
+	reader := NewLinesReader(strings.NewReader(input))
+
+	nested, err := reader.Read()
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{nested: map[string]*Nested{"a": {isValue: true, value: 1}}}, nested)
+		assert.Equal(t, 1, reader.Line())
+	}
+
+	nested, err = reader.Read()
+	if assert.Nil(t, err) {
+		assert.Equal(t, "[1,2]", nested.ToJSONString())
+		assert.Equal(t, 4, reader.Line())
+	}
+
+	nested, err = reader.Read()
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{isValue: true, value: "string"}, nested)
+	}
+
+	nested, err = reader.Read()
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{isValue: true, value: 42.5}, nested)
+		assert.Equal(t, 6, reader.Line())
+	}
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+
+	reader = NewLinesReader(strings.NewReader("{\"a\": 1}\n{\"a\": \n{\"a\": 3}\n"))
+
+	_, err = reader.Read()
+	assert.Nil(t, err)
+
+	_, err = reader.Read()
+	assert.EqualError(t, err, "line 2: unexpected end of JSON input")
+
+	var lineErr *LineError
+	if assert.True(t, errors.As(err, &lineErr)) {
+		assert.Equal(t, 2, lineErr.Line)
+	}
+
+	_, err = reader.Read()
+	assert.EqualError(t, err, "line 2: unexpected end of JSON input")
+
+	reader = NewLinesReader(strings.NewReader("{\"a\": 1}\nnot json\n{\"a\": \n{\"a\": 3}\n"))
+	reader.SetContinueOnError(true)
+
+	values := []any{}
+	for {
+		nested, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if assert.Nil(t, err) {
+			value, _ := nested.GetValue("a")
+			values = append(values, value)
+		}
+	}
+
+	assert.Equal(t, []any{1, 3}, values)
+	if assert.Len(t, reader.Errors(), 2) {
+		assert.Equal(t, 2, reader.Errors()[0].Line)
+		assert.Equal(t, 3, reader.Errors()[1].Line)
+	}
+}
+
+func Test_LinesReaderLimits(t *testing.T) {
+	long := `{"a": "` + strings.Repeat("x", 10000) + `"}`
+
+	// длинная строка не сохраняется, остаток строки пропускается
+	reader := NewLinesReader(strings.NewReader(long + "\n{\"a\": 2}\n" + long))
+	reader.SetMaxLineSize(100)
+
+	_, err := reader.Read()
+	assert.EqualError(t, err, "line 1: line is too long")
+	assert.True(t, errors.Is(err, ErrLineTooLong))
+
+	reader = NewLinesReader(strings.NewReader(long + "\n{\"a\": 2}\n" + long))
+	reader.SetMaxLineSize(100)
+	reader.SetContinueOnError(true)
+
+	nested, err := reader.Read()
+	if assert.Nil(t, err) {
+		assert.Equal(t, `{"a":2}`, nested.ToJSONString())
+	}
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 3, reader.Line())
+	assert.Len(t, reader.Errors(), 2)
+
+	// строка ровно максимального размера
+	reader = NewLinesReader(strings.NewReader("[1, 2]\r\n"))
+	reader.SetMaxLineSize(len("[1, 2]\r"))
+
+	nested, err = reader.Read()
+	if assert.Nil(t, err) {
+		assert.Equal(t, `[1,2]`, nested.ToJSONString())
+	}
+
+	// сохраняются только первые ошибки, обработчик получает все
+	reader = NewLinesReader(strings.NewReader(strings.Repeat("not json\n", maxLineErrors+10)))
+	reader.SetContinueOnError(true)
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+	assert.Len(t, reader.Errors(), maxLineErrors)
+
+	reader = NewLinesReader(strings.NewReader(strings.Repeat("not json\n", maxLineErrors+10)))
+	reader.SetContinueOnError(true)
+
+	lines := []int{}
+	reader.SetErrorHandler(func(err *LineError) {
+		lines = append(lines, err.Line)
+	})
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+	assert.Len(t, lines, maxLineErrors+10)
+	assert.Equal(t, maxLineErrors+10, lines[len(lines)-1])
+	assert.Empty(t, reader.Errors())
+}
+
+func Test_LinesWriter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := NewLinesWriter(buffer)
+
+	nested := testNested()
+
+	assert.Nil(t, writer.Write(&nested))
+	assert.Nil(t, writer.Write(&Nested{isValue: true, value: "string"}))
+	assert.EqualError(t, writer.Write(&Nested{isValue: true, value: math.NaN()}), "json: unsupported value: NaN")
+	assert.Nil(t, writer.Write(FromJSONString(`{"b": [1, 2], "a": "<&>"}`)))
+
+	assert.Equal(t, "", buffer.String())
+	assert.Nil(t, writer.Flush())
+
+	assert.Equal(t,
+		nested.ToJSONString()+"\n\"string\"\n{\"a\":\"<&>\",\"b\":[1,2]}\n",
+		buffer.String(),
+	)
+
+	reader := NewLinesReader(buffer)
+
+	read, err := reader.Read()
+	if assert.Nil(t, err) {
+		assert.True(t, Equals(&nested, read))
+	}
+}
@@ -0,0 +1,504 @@
+package nested
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Максимальная глубина вложенности при разборе MessagePack.
+const msgpackMaxDepth = 10000
+
+// Тип расширения MessagePack для меток времени.
+const msgpackTimestampExt = -1
+
+// Конвертация объекта в формат MessagePack.
+//
+// Сохраняются различия между типами значений: целые числа (int, int8-int64, uint, uint8-uint64)
+// записываются в целочисленном формате минимальной длины, float32 и float64 - в формате с плавающей точкой
+// соответствующей точности, string - как строка, []byte - как двоичные данные (bin),
+// time.Time - как расширение timestamp (тип -1), bool и nil - соответствующими форматами.
+//
+// Ключи словарей сортируются в алфавитном порядке, поэтому результат детерминирован.
+// Для значений других типов вернется ошибка.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"id": 42, "tags": ["a", "b"]}`)
+//
+//	data, err := nested.MarshalMsgpack()
+func (j *Nested) MarshalMsgpack() ([]byte, error) {
+	return appendMsgpack(nil, j)
+}
+
+// Заполнение объекта из данных в формате MessagePack.
+//
+// Предыдущее содержимое объекта заменяется.
+// Целые числа восстанавливаются как int (или uint64, если значение не помещается в int64),
+// числа с плавающей точкой - как float32 или float64 в зависимости от формата,
+// строки - как string, двоичные данные - как []byte, метки времени - как time.Time в UTC.
+//
+// Ключи словарей должны быть строками. Данные проверяются перед выделением памяти,
+// поэтому функцию можно использовать для недоверенного ввода.
+//
+// Пример:
+//
+//	nested := Nested{}
+//	err := nested.UnmarshalMsgpack(data)
+//
+//	nested.GetValue("id") // 42, nil
+func (j *Nested) UnmarshalMsgpack(data []byte) error {
+	decoder := msgpackDecoder{data: data}
+
+	nested, err := decoder.decode(0)
+	if err != nil {
+		return err
+	}
+
+	if decoder.offset != len(data) {
+		return fmt.Errorf("msgpack: unexpected data at offset %d", decoder.offset)
+	}
+
+	j.replace(nested)
+
+	return nil
+}
+
+// Рекурсивная запись объекта в формате MessagePack.
+func appendMsgpack(b []byte, j *Nested) ([]byte, error) {
+	if j == nil {
+		return append(b, 0xc0), nil
+	}
+
+	if j.IsValue() {
+		return appendMsgpackValue(b, j.value)
+	}
+
+	if j.IsArray() {
+		b = appendMsgpackHeader(b, len(j.array), 0x90, 0xdc, 0xdd)
+
+		for _, element := range j.array {
+			var err error
+			if b, err = appendMsgpack(b, element); err != nil {
+				return nil, err
+			}
+		}
+
+		return b, nil
+	}
+
+	b = appendMsgpackHeader(b, len(j.nested), 0x80, 0xde, 0xdf)
+
+	for _, k := range sortedKeys(j.nested) {
+		b = appendMsgpackString(b, k)
+
+		var err error
+		if b, err = appendMsgpack(b, j.nested[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// Запись скалярного значения в формате MessagePack.
+func appendMsgpackValue(b []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case int:
+		return appendMsgpackInt(b, int64(v)), nil
+	case int8:
+		return appendMsgpackInt(b, int64(v)), nil
+	case int16:
+		return appendMsgpackInt(b, int64(v)), nil
+	case int32:
+		return appendMsgpackInt(b, int64(v)), nil
+	case int64:
+		return appendMsgpackInt(b, v), nil
+	case uint:
+		return appendMsgpackUint(b, uint64(v)), nil
+	case uint8:
+		return appendMsgpackUint(b, uint64(v)), nil
+	case uint16:
+		return appendMsgpackUint(b, uint64(v)), nil
+	case uint32:
+		return appendMsgpackUint(b, uint64(v)), nil
+	case uint64:
+		return appendMsgpackUint(b, v), nil
+	case float32:
+		return binary.BigEndian.AppendUint32(append(b, 0xca), math.Float32bits(v)), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v)), nil
+	case string:
+		return appendMsgpackString(b, v), nil
+	case []byte:
+		switch {
+		case len(v) <= math.MaxUint8:
+			b = append(b, 0xc4, byte(len(v)))
+		case len(v) <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(len(v)))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(len(v)))
+		}
+		return append(b, v...), nil
+	case time.Time:
+		return appendMsgpackTime(b, v), nil
+	}
+
+	return nil, fmt.Errorf("msgpack: unsupported type %T", value)
+}
+
+// Запись знакового целого числа в формате минимальной длины.
+func appendMsgpackInt(b []byte, v int64) []byte {
+	if v >= 0 {
+		return appendMsgpackUint(b, uint64(v))
+	}
+
+	switch {
+	case v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v))
+	}
+
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+}
+
+// Запись беззнакового целого числа в формате минимальной длины.
+func appendMsgpackUint(b []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	}
+
+	return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+}
+
+// Запись строки.
+func appendMsgpackString(b []byte, v string) []byte {
+	switch {
+	case len(v) <= 31:
+		b = append(b, 0xa0|byte(len(v)))
+	case len(v) <= math.MaxUint8:
+		b = append(b, 0xd9, byte(len(v)))
+	case len(v) <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(len(v)))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(len(v)))
+	}
+
+	return append(b, v...)
+}
+
+// Запись заголовка массива или словаря.
+func appendMsgpackHeader(b []byte, length int, fix, code16, code32 byte) []byte {
+	switch {
+	case length <= 15:
+		return append(b, fix|byte(length))
+	case length <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(length))
+	}
+
+	return binary.BigEndian.AppendUint32(append(b, code32), uint32(length))
+}
+
+// Запись метки времени в формате timestamp 32, 64 или 96.
+func appendMsgpackTime(b []byte, v time.Time) []byte {
+	seconds := v.Unix()
+	nanoseconds := int64(v.Nanosecond())
+
+	if seconds>>34 == 0 {
+		data := uint64(nanoseconds)<<34 | uint64(seconds)
+
+		if data&0xffffffff00000000 == 0 {
+			b = append(b, 0xd6, byte(msgpackTimestampExt&0xff))
+			return binary.BigEndian.AppendUint32(b, uint32(data))
+		}
+
+		b = append(b, 0xd7, byte(msgpackTimestampExt&0xff))
+		return binary.BigEndian.AppendUint64(b, data)
+	}
+
+	b = append(b, 0xc7, 12, byte(msgpackTimestampExt&0xff))
+	b = binary.BigEndian.AppendUint32(b, uint32(nanoseconds))
+
+	return binary.BigEndian.AppendUint64(b, uint64(seconds))
+}
+
+// Разбор данных в формате MessagePack.
+type msgpackDecoder struct {
+	data   []byte
+	offset int
+}
+
+// Чтение n байт с проверкой границ.
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.offset {
+		return nil, fmt.Errorf("msgpack: unexpected end of data at offset %d", d.offset)
+	}
+
+	result := d.data[d.offset : d.offset+n]
+	d.offset += n
+
+	return result, nil
+}
+
+// Чтение беззнакового целого длиной size байт.
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	data, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+
+	switch size {
+	case 1:
+		return uint64(data[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(data)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(data)), nil
+	}
+
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// Рекурсивный разбор очередного объекта.
+func (d *msgpackDecoder) decode(depth int) (*Nested, error) {
+	if depth > msgpackMaxDepth {
+		return nil, fmt.Errorf("msgpack: max depth %d exceeded at offset %d", msgpackMaxDepth, d.offset)
+	}
+
+	start := d.offset
+
+	header, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	code := header[0]
+
+	switch {
+	case code <= 0x7f:
+		return &Nested{isValue: true, value: int(code)}, nil
+	case code >= 0xe0:
+		return &Nested{isValue: true, value: int(int8(code))}, nil
+	case code&0xf0 == 0x80:
+		return d.decodeMap(int(code&0x0f), depth)
+	case code&0xf0 == 0x90:
+		return d.decodeArray(int(code&0x0f), depth)
+	case code&0xe0 == 0xa0:
+		return d.decodeString(int(code & 0x1f))
+	}
+
+	switch code {
+	case 0xc0:
+		return &Nested{isValue: true, value: nil}, nil
+	case 0xc2:
+		return &Nested{isValue: true, value: false}, nil
+	case 0xc3:
+		return &Nested{isValue: true, value: true}, nil
+	case 0xc4, 0xc5, 0xc6:
+		length, err := d.readUint(1 << (code - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := d.read(int(length))
+		if err != nil {
+			return nil, err
+		}
+
+		return &Nested{isValue: true, value: append([]byte{}, data...)}, nil
+	case 0xc7, 0xc8, 0xc9:
+		length, err := d.readUint(1 << (code - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeExt(int(length), start)
+	case 0xca:
+		bits, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Nested{isValue: true, value: math.Float32frombits(uint32(bits))}, nil
+	case 0xcb:
+		bits, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Nested{isValue: true, value: math.Float64frombits(bits)}, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		value, err := d.readUint(1 << (code - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+
+		if value > math.MaxInt64 {
+			return &Nested{isValue: true, value: value}, nil
+		}
+
+		return &Nested{isValue: true, value: int(value)}, nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (code - 0xd0)
+
+		value, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+
+		// расширение знака
+		shift := 64 - 8*size
+
+		return &Nested{isValue: true, value: int(int64(value<<shift) >> shift)}, nil
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1<<(code-0xd4), start)
+	case 0xd9, 0xda, 0xdb:
+		length, err := d.readUint(1 << (code - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeString(int(length))
+	case 0xdc, 0xdd:
+		length, err := d.readUint(2 << (code - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeArray(int(length), depth)
+	case 0xde, 0xdf:
+		length, err := d.readUint(2 << (code - 0xde))
+		if err != nil {
+			return nil, err
+		}
+
+		return d.decodeMap(int(length), depth)
+	}
+
+	return nil, fmt.Errorf("msgpack: unknown format 0x%02x at offset %d", code, start)
+}
+
+// Разбор строки длиной length байт.
+func (d *msgpackDecoder) decodeString(length int) (*Nested, error) {
+	data, err := d.read(length)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Nested{isValue: true, value: string(data)}, nil
+}
+
+// Разбор массива из length элементов.
+func (d *msgpackDecoder) decodeArray(length int, depth int) (*Nested, error) {
+	// каждый элемент занимает хотя бы один байт
+	if length > len(d.data)-d.offset {
+		return nil, fmt.Errorf("msgpack: unexpected end of data at offset %d", d.offset)
+	}
+
+	array := make([]*Nested, 0, length)
+
+	for index := 0; index < length; index++ {
+		element, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		array = append(array, element)
+	}
+
+	return &Nested{isArray: true, array: array}, nil
+}
+
+// Разбор словаря из length пар ключ-значение.
+func (d *msgpackDecoder) decodeMap(length int, depth int) (*Nested, error) {
+	// каждая пара занимает хотя бы два байта
+	if length > (len(d.data)-d.offset)/2 {
+		return nil, fmt.Errorf("msgpack: unexpected end of data at offset %d", d.offset)
+	}
+
+	nested := make(map[string]*Nested, length)
+
+	for index := 0; index < length; index++ {
+		keyOffset := d.offset
+
+		key, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		k, ok := key.value.(string)
+		if !ok || !key.IsValue() {
+			return nil, fmt.Errorf("msgpack: map key at offset %d must be a string", keyOffset)
+		}
+
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		nested[k] = value
+	}
+
+	return &Nested{nested: nested}, nil
+}
+
+// Разбор расширения с данными длиной length байт.
+//
+// Поддерживается только расширение timestamp.
+func (d *msgpackDecoder) decodeExt(length int, start int) (*Nested, error) {
+	extType, err := d.read(1)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := d.read(length)
+	if err != nil {
+		return nil, err
+	}
+
+	if int8(extType[0]) != msgpackTimestampExt {
+		return nil, fmt.Errorf("msgpack: unsupported extension type %d at offset %d", int8(extType[0]), start)
+	}
+
+	var seconds, nanoseconds int64
+
+	switch length {
+	case 4:
+		seconds = int64(binary.BigEndian.Uint32(data))
+	case 8:
+		value := binary.BigEndian.Uint64(data)
+		nanoseconds = int64(value >> 34)
+		seconds = int64(value & 0x3ffffffff)
+	case 12:
+		nanoseconds = int64(binary.BigEndian.Uint32(data))
+		seconds = int64(binary.BigEndian.Uint64(data[4:]))
+	default:
+		return nil, fmt.Errorf("msgpack: invalid timestamp length %d at offset %d", length, start)
+	}
+
+	if nanoseconds > 999999999 {
+		return nil, fmt.Errorf("msgpack: invalid timestamp nanoseconds at offset %d", start)
+	}
+
+	return &Nested{isValue: true, value: time.Unix(seconds, nanoseconds).UTC()}, nil
+}
@@ -0,0 +1,192 @@
+package nested
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MarshalMsgpack(t *testing.T) {
+	cases := []struct {
+		value any
+		data  []byte
+	}{
+		{nil, []byte{0xc0}},
+		{false, []byte{0xc2}},
+		{true, []byte{0xc3}},
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0xcc, 0x80}},
+		{uint16(256), []byte{0xcd, 0x01, 0x00}},
+		{int64(1 << 32), []byte{0xcf, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}},
+		{-1, []byte{0xff}},
+		{-32, []byte{0xe0}},
+		{int8(-33), []byte{0xd0, 0xdf}},
+		{-129, []byte{0xd1, 0xff, 0x7f}},
+		{int32(math.MinInt32), []byte{0xd2, 0x80, 0x00, 0x00, 0x00}},
+		{math.MinInt64, []byte{0xd3, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{uint64(math.MaxUint64), []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{float32(1.5), []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{strings.Repeat("a", 32), append([]byte{0xd9, 32}, strings.Repeat("a", 32)...)},
+		{[]byte("abc"), []byte{0xc4, 0x03, 'a', 'b', 'c'}},
+		{time.Unix(1, 0), []byte{0xd6, 0xff, 0x00, 0x00, 0x00, 0x01}},
+		{time.Unix(1, 1), []byte{0xd7, 0xff, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x01}},
+		{time.Unix(-1, 0), []byte{0xc7, 0x0c, 0xff, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+
+	for _, c := range cases {
+		nested := Nested{isValue: true, value: c.value}
+
+		data, err := nested.MarshalMsgpack()
+		if assert.Nil(t, err) {
+			assert.Equal(t, c.data, data, "%T %v", c.value, c.value)
+		}
+	}
+
+	nested := FromJSONString(`{"b": [1, "a"], "a": {}}`)
+
+	data, err := nested.MarshalMsgpack()
+	if assert.Nil(t, err) {
+		assert.Equal(t, []byte{0x82, 0xa1, 'a', 0x80, 0xa1, 'b', 0x92, 0x01, 0xa1, 'a'}, data)
+	}
+
+	nested = &Nested{isArray: true, array: []*Nested{nil}}
+
+	data, err = nested.MarshalMsgpack()
+	if assert.Nil(t, err) {
+		assert.Equal(t, []byte{0x91, 0xc0}, data)
+	}
+
+	nested = &Nested{nested: map[string]*Nested{"a": {isValue: true, value: struct{}{}}}}
+
+	_, err = nested.MarshalMsgpack()
+	assert.EqualError(t, err, "msgpack: unsupported type struct {}")
+}
+
+func Test_UnmarshalMsgpack(t *testing.T) {
+	nested := testNested()
+	nested.nested["types"] = &Nested{nested: map[string]*Nested{
+		"nil":     {isValue: true, value: nil},
+		"bool":    {isValue: true, value: true},
+		"int":     {isValue: true, value: -100000},
+		"uint":    {isValue: true, value: uint64(math.MaxUint64)},
+		"float32": {isValue: true, value: float32(0.5)},
+		"float64": {isValue: true, value: 0.1},
+		"string":  {isValue: true, value: strings.Repeat("a", 70000)},
+		"binary":  {isValue: true, value: bytes.Repeat([]byte{1}, 300)},
+		"time":    {isValue: true, value: time.Date(2024, 3, 18, 10, 0, 0, 5, time.UTC)},
+		"old":     {isValue: true, value: time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)},
+		"array":   {isArray: true, array: []*Nested{}},
+		"map":     {nested: map[string]*Nested{}},
+	}}
+
+	data, err := nested.MarshalMsgpack()
+	if assert.Nil(t, err) {
+		result := Nested{isValue: true, value: "previous"}
+
+		if assert.Nil(t, result.UnmarshalMsgpack(data)) {
+			assert.Equal(t, nested, result)
+		}
+
+		// подписчики объекта сохраняются и получают событие замены
+		observed := FromJSONString(`{"a": 1}`)
+
+		events := []ChangeEvent{}
+		observed.Subscribe(func(event ChangeEvent) {
+			events = append(events, event)
+		})
+
+		if assert.Nil(t, observed.UnmarshalMsgpack(data)) {
+			assert.Nil(t, observed.SetValue(2, "types", "int"))
+
+			if assert.Len(t, events, 2) {
+				assert.Equal(t, ChangeSet, events[0].Op)
+				assert.Equal(t, []string{}, events[0].Path)
+				assert.Equal(t, []string{"types", "int"}, events[1].Path)
+			}
+		}
+	}
+
+	array := &Nested{isArray: true, array: []*Nested{}}
+	for index := 0; index < 70000; index++ {
+		array.ArrayAddValue(index)
+	}
+
+	data, err = array.MarshalMsgpack()
+	if assert.Nil(t, err) {
+		assert.Equal(t, byte(0xdd), data[0])
+
+		result := Nested{}
+		if assert.Nil(t, result.UnmarshalMsgpack(data)) {
+			assert.Equal(t, *array, result)
+		}
+	}
+
+	cases := []struct {
+		data []byte
+		err  string
+	}{
+		{[]byte{}, "msgpack: unexpected end of data at offset 0"},
+		{[]byte{0x92, 0x01}, "msgpack: unexpected end of data at offset 1"},
+		{[]byte{0xdd, 0xff, 0xff, 0xff, 0xff}, "msgpack: unexpected end of data at offset 5"},
+		{[]byte{0xa3, 'a'}, "msgpack: unexpected end of data at offset 1"},
+		{[]byte{0x01, 0x02}, "msgpack: unexpected data at offset 1"},
+		{[]byte{0xc1}, "msgpack: unknown format 0xc1 at offset 0"},
+		{[]byte{0x81, 0x01, 0x01}, "msgpack: map key at offset 1 must be a string"},
+		{[]byte{0xd4, 0x05, 0x00}, "msgpack: unsupported extension type 5 at offset 0"},
+		{[]byte{0xd5, 0xff, 0x00, 0x00}, "msgpack: invalid timestamp length 2 at offset 0"},
+	}
+
+	for _, c := range cases {
+		result := Nested{}
+		assert.EqualError(t, result.UnmarshalMsgpack(c.data), c.err)
+	}
+
+	deep := append(bytes.Repeat([]byte{0x91}, msgpackMaxDepth+1), 0xc0)
+
+	result := Nested{}
+	assert.EqualError(t, result.UnmarshalMsgpack(deep), "msgpack: max depth 10000 exceeded at offset 10001")
+}
+
+func FuzzMsgpack(f *testing.F) {
+	nested := testNested()
+	data, _ := nested.MarshalMsgpack()
+	f.Add(data)
+
+	for _, value := range []any{nil, true, -33, uint64(math.MaxUint64), float32(1.5), 0.1, "abc", []byte("abc"), time.Unix(1, 1)} {
+		data, _ := (&Nested{isValue: true, value: value}).MarshalMsgpack()
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		first := Nested{}
+		if err := first.UnmarshalMsgpack(data); err != nil {
+			return
+		}
+
+		encoded, err := first.MarshalMsgpack()
+		if err != nil {
+			t.Fatalf("marshal decoded value: %v", err)
+		}
+
+		second := Nested{}
+		if err := second.UnmarshalMsgpack(encoded); err != nil {
+			t.Fatalf("unmarshal encoded value: %v", err)
+		}
+
+		reencoded, err := second.MarshalMsgpack()
+		if err != nil {
+			t.Fatalf("marshal decoded value: %v", err)
+		}
+
+		if !bytes.Equal(encoded, reencoded) {
+			t.Fatalf("round trip mismatch: %x != %x", encoded, reencoded)
+		}
+	})
+}
@@ -72,7 +72,13 @@ type Nested struct {
 	nested map[string]*Nested // вложенный объект вида ключ-значение
 	array  []*Nested          // массив объектов
 
+	order []string // порядок добавления ключей словаря через Set, см. [Encoder.SetSortKeys]
+
 	value any // скалярное значение
+
+	hash *[HashSize]byte // кешированный хеш объекта, см. [Nested.Hash]
+
+	observation *observation // подписчики на изменения и путь объекта, см. [Nested.Subscribe]
 }
 
 // Проверка, является ли объект массивом.
@@ -117,29 +123,27 @@ func (j *Nested) Length() int {
 // Следует учитывать, что внутри структуры используются указатели. Если структура была инициализирована
 // указателями на внешние объекты, они тоже могут стать недоступны.
 func (j *Nested) Clear() error {
-	if j.IsValue() {
-		j.isValue = false
-		j.value = nil
+	previous := j.snapshot()
+	nested, array := j.nested, j.array
 
-		return nil
-	}
-
-	if j.IsArray() {
-		for _, element := range j.array[:] {
-			element.Clear()
-			element = nil
-		}
+	j.isValue = false
+	j.isArray = false
+	j.value = nil
+	j.nested = nil
+	j.order = nil
+	j.array = nil
 
-		j.isArray = false
-		j.array = nil
+	// подписчики уведомляются до очистки вложенных объектов, пока прежнее содержимое еще доступно
+	j.notify(ChangeSet, previous, j)
 
-		return nil
+	for _, element := range array {
+		element.Clear()
 	}
 
-	for k := range j.nested {
-		j.nested[k].Clear()
-		j.nested[k] = nil
-		delete(j.nested, k)
+	for k := range nested {
+		nested[k].Clear()
+		nested[k] = nil
+		delete(nested, k)
 	}
 
 	return nil
@@ -158,35 +162,22 @@ func (j *Nested) Get(keys ...string) (*Nested, error) {
 		return nil, fmt.Errorf("keys list must contain at least one key")
 	}
 
-	if j.IsValue() {
-		return nil, fmt.Errorf("is value")
-	}
-
-	if j.IsArray() {
-		return nil, fmt.Errorf("is array")
-	}
-
-	currentKey := keys[0]
+	current := j
 
-	value, ok := j.nested[currentKey]
-	if !ok {
-		return nil, fmt.Errorf("key '%s' not found", currentKey)
-	}
-
-	if len(keys) == 1 {
-		return value, nil
-	}
+	for index, key := range keys {
+		if err := nestedError(current, keys[:index]); err != nil {
+			return nil, err
+		}
 
-	res, err := value.Get(keys[1:]...)
-	if err != nil {
-		formatString := "%s.%s"
-		if len(keys) == 2 {
-			formatString = "%s: %s"
+		next, ok := current.nested[key]
+		if !ok {
+			return nil, pathError(keys[:index], "key '%s' not found", key)
 		}
-		return nil, fmt.Errorf(formatString, currentKey, err.Error())
+
+		current = next
 	}
 
-	return res, err
+	return current, nil
 }
 
 // Помещение вложенного объекта по цепочке ключей.
@@ -204,40 +195,50 @@ func (j *Nested) Set(nested *Nested, keys ...string) error {
 		return fmt.Errorf("keys list must contain at least one key")
 	}
 
-	if j.IsValue() {
-		return fmt.Errorf("is value")
-	}
+	current := j
 
-	if j.IsArray() {
-		return fmt.Errorf("is array")
-	}
+	for index, key := range keys {
+		if err := nestedError(current, keys[:index]); err != nil {
+			return err
+		}
 
-	currentKey := keys[0]
+		// объект может быть сохранен в нескольких местах, поэтому кеш сбрасывается и по цепочке ключей
+		current.hash = nil
 
-	if j.nested == nil {
-		j.nested = map[string]*Nested{}
-	}
+		if current.nested == nil {
+			current.nested = map[string]*Nested{}
+		}
 
-	if len(keys) == 1 {
-		j.nested[currentKey] = nested
-		return nil
-	}
+		if index == len(keys)-1 {
+			old, ok := current.nested[key]
+			if !ok {
+				current.order = append(current.order, key)
+			}
 
-	_, ok := j.nested[currentKey]
-	if !ok {
-		j.nested[currentKey] = &Nested{}
-	}
+			current.nested[key] = nested
+			current.notify(ChangeSet, old, nested, key)
 
-	err := j.nested[currentKey].Set(nested, keys[1:]...)
-	if err != nil {
-		formatString := "%s.%s"
-		if len(keys) == 2 {
-			formatString = "%s: %s"
+			return nil
+		}
+
+		next, ok := current.nested[key]
+		if !ok {
+			// промежуточные объекты заполняются до подключения к отслеживанию,
+			// чтобы подписчики получили одно событие добавления самого верхнего из них
+			created := &Nested{}
+			created.Set(nested, keys[index+1:]...)
+
+			current.nested[key] = created
+			current.order = append(current.order, key)
+			current.notify(ChangeAdd, nil, created, key)
+
+			return nil
 		}
-		return fmt.Errorf(formatString, currentKey, err.Error())
+
+		current = next
 	}
 
-	return err
+	return nil
 }
 
 // Получение скалярного значения по цепочке ключей.
@@ -290,8 +291,11 @@ func (j *Nested) GetValue(keys ...string) (any, error) {
 // В этом случае он станет объектом-значением.
 func (j *Nested) SetValue(value any, keys ...string) error {
 	if (j.IsEmpty() || j.IsValue()) && len(keys) == 0 {
+		old := j.snapshot()
+
 		j.isValue = true
 		j.value = value
+		j.notify(ChangeSet, old, j)
 
 		return nil
 	}
@@ -350,7 +354,11 @@ func (j *Nested) GetMap(keys ...string) (map[string]*Nested, error) {
 // Если исходный объект непустой, удаление старых элементов не производится, и указатели на них останутся корректными.
 func (j *Nested) SetMap(nested map[string]*Nested, keys ...string) error {
 	if (j.IsEmpty() || j.IsNested()) && len(keys) == 0 {
+		old := j.snapshot()
+
 		j.nested = nested
+		j.order = nil
+		j.notify(ChangeSet, old, j)
 
 		return nil
 	}
@@ -366,6 +374,16 @@ func (j *Nested) SetMap(nested map[string]*Nested, keys ...string) error {
 // Все вложенные объекты до последнего в цепочке должны быть вида ключ-значение. Последний - массивом.
 // Можно не передавать ключи, тогда исходный объект должен быть массивом.
 func (j *Nested) GetArray(keys ...string) ([]*Nested, error) {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	return nested.array, nil
+}
+
+// Получение вложенного объекта-массива по цепочке ключей с проверками из [Nested.GetArray].
+func (j *Nested) getArrayNode(keys ...string) (*Nested, error) {
 	if j.IsEmpty() {
 		return nil, fmt.Errorf("is empty")
 	}
@@ -376,7 +394,7 @@ func (j *Nested) GetArray(keys ...string) ([]*Nested, error) {
 
 	if len(keys) == 0 {
 		if j.IsArray() {
-			return j.array, nil
+			return j, nil
 		} else if j.IsNested() {
 			return nil, fmt.Errorf("is nested")
 		}
@@ -395,7 +413,7 @@ func (j *Nested) GetArray(keys ...string) ([]*Nested, error) {
 		return nil, fmt.Errorf("%s: is nested", strings.Join(keys[:], "."))
 	}
 
-	return nested.array, nil
+	return nested, nil
 }
 
 // Сохранение объекта-массива из аргумента по цепочке ключей.
@@ -411,8 +429,11 @@ func (j *Nested) GetArray(keys ...string) ([]*Nested, error) {
 // В этом случае он станет объектом-массивом.
 func (j *Nested) SetArray(array []*Nested, keys ...string) error {
 	if (j.IsEmpty() || j.IsArray()) && len(keys) == 0 {
+		old := j.snapshot()
+
 		j.isArray = true
 		j.array = array
+		j.notify(ChangeSet, old, j)
 
 		return nil
 	}
@@ -446,8 +467,15 @@ func (j *Nested) Delete(keys ...string) error {
 	}
 
 	if len(keys) == 1 {
+		old, ok := j.nested[keys[0]]
 		j.nested[keys[0]] = nil
 		delete(j.nested, keys[0])
+		j.removeOrder(keys[0])
+
+		if ok {
+			j.notify(ChangeDelete, old, nil, keys[0])
+		}
+
 		return nil
 	}
 
@@ -466,8 +494,16 @@ func (j *Nested) Delete(keys ...string) error {
 		return fmt.Errorf("%s: is array", strings.Join(keysWithoutLast, "."))
 	}
 
-	nested.nested[keys[len(keys)-1]] = nil
-	delete(nested.nested, keys[len(keys)-1])
+	lastKey := keys[len(keys)-1]
+
+	old, ok := nested.nested[lastKey]
+	nested.nested[lastKey] = nil
+	delete(nested.nested, lastKey)
+	nested.removeOrder(lastKey)
+
+	if ok {
+		nested.notify(ChangeDelete, old, nil, lastKey)
+	}
 
 	return nil
 }
@@ -488,6 +524,7 @@ func (j *Nested) ArrayAdd(element *Nested, keys ...string) error {
 		}
 
 		j.array = append(j.array, element)
+		j.notify(ChangeAdd, nil, element, strconv.Itoa(len(j.array)-1))
 		return nil
 	}
 
@@ -513,6 +550,7 @@ func (j *Nested) ArrayAdd(element *Nested, keys ...string) error {
 	}
 
 	nested.array = append(nested.array, element)
+	nested.notify(ChangeAdd, nil, element, strconv.Itoa(len(nested.array)-1))
 
 	return nil
 }
@@ -749,20 +787,197 @@ func (j *Nested) ArrayFindOne(f func(element *Nested) bool, keys ...string) (*Ne
 //
 //	nested.GetArray() // []*Nested{{}}, nil
 func (j *Nested) ArrayDelete(f func(element *Nested) bool, keys ...string) error {
-	array, err := j.GetArray(keys...)
+	removed, err := j.arrayDelete(f, keys...)
 	if err != nil {
 		return err
 	}
 
+	for _, element := range removed {
+		element.Clear()
+	}
+
+	return nil
+}
+
+// Удаление элементов массива без их очистки.
+//
+// Возвращает удаленные элементы в порядке удаления (с конца массива).
+func (j *Nested) arrayDelete(f func(element *Nested) bool, keys ...string) ([]*Nested, error) {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	array := nested.array
+	removed := []*Nested{}
+
 	for index := len(array) - 1; index >= 0; index-- {
 		if f(array[index]) {
-			array[index].Clear()
+			element := array[index]
+
+			removed = append(removed, element)
 			array[index] = nil
 			array = append(array[:index], array[index+1:]...)
+			nested.array = array
+
+			// подписчики уведомляются после удаления, но до очистки, пока удаленный элемент еще доступен
+			nested.notify(ChangeRemove, element, nil, strconv.Itoa(index))
 		}
 	}
 
-	return j.SetArray(array, keys...)
+	return removed, nil
+}
+
+// Получение элемента массива по индексу по цепочке ключей.
+//
+// Отрицательный индекс отсчитывается от конца массива: -1 - последний элемент.
+// Если индекс вне диапазона, вернется ошибка.
+func (j *Nested) ArrayGet(index int, keys ...string) (*Nested, error) {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err = arrayIndex(index, len(nested.array), keys)
+	if err != nil {
+		return nil, err
+	}
+
+	return nested.array[index], nil
+}
+
+// Вставка указателя на объект в массив по цепочке ключей перед элементом с индексом index.
+//
+// Индекс может быть равен длине массива, тогда элемент добавляется в конец.
+// Отрицательный индекс отсчитывается от конца массива: -1 - добавление в конец, -2 - перед последним элементом.
+// Если индекс вне диапазона, вернется ошибка.
+func (j *Nested) ArrayInsert(index int, element *Nested, keys ...string) error {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return err
+	}
+
+	index, err = arrayIndex(index, len(nested.array)+1, keys)
+	if err != nil {
+		return err
+	}
+
+	nested.array = slices.Insert(nested.array, index, element)
+	nested.notify(ChangeAdd, nil, element, strconv.Itoa(index))
+
+	return nil
+}
+
+// Замена элемента массива по индексу по цепочке ключей на указатель на объект.
+//
+// Отрицательный индекс отсчитывается от конца массива: -1 - последний элемент.
+// Если индекс вне диапазона, вернется ошибка. Замененный элемент не очищается.
+func (j *Nested) ArraySet(index int, element *Nested, keys ...string) error {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return err
+	}
+
+	index, err = arrayIndex(index, len(nested.array), keys)
+	if err != nil {
+		return err
+	}
+
+	old := nested.array[index]
+	nested.array[index] = element
+	nested.notify(ChangeSet, old, element, strconv.Itoa(index))
+
+	return nil
+}
+
+// Удаление элемента массива по индексу по цепочке ключей.
+//
+// Отрицательный индекс отсчитывается от конца массива: -1 - последний элемент.
+// Если индекс вне диапазона, вернется ошибка.
+//
+// В отличие от [Nested.ArrayDelete], удаленный элемент не очищается и возвращается.
+func (j *Nested) ArrayRemoveAt(index int, keys ...string) (*Nested, error) {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err = arrayIndex(index, len(nested.array), keys)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := nested.array[index]
+	nested.array = slices.Delete(nested.array, index, index+1)
+
+	nested.notify(ChangeRemove, removed, nil, strconv.Itoa(index))
+
+	return removed, nil
+}
+
+// Перемещение элемента массива по цепочке ключей с индекса from на индекс to.
+//
+// После перемещения элемент находится по индексу to. Отрицательные индексы отсчитываются от конца массива.
+// Если один из индексов вне диапазона, вернется ошибка.
+//
+// Подписчики получают события удаления элемента с прежнего индекса и добавления на новый.
+func (j *Nested) ArrayMove(from, to int, keys ...string) error {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return err
+	}
+
+	from, err = arrayIndex(from, len(nested.array), keys)
+	if err != nil {
+		return err
+	}
+
+	to, err = arrayIndex(to, len(nested.array), keys)
+	if err != nil {
+		return err
+	}
+
+	if from == to {
+		return nil
+	}
+
+	element, err := j.ArrayRemoveAt(from, keys...)
+	if err != nil {
+		return err
+	}
+
+	return j.ArrayInsert(to, element, keys...)
+}
+
+// Приведение индекса массива длины length к диапазону [0, length) с учетом отрицательных индексов.
+//
+// keys - цепочка ключей до массива для текста ошибки.
+func arrayIndex(index, length int, keys []string) (int, error) {
+	normalized := index
+	if normalized < 0 {
+		normalized += length
+	}
+
+	if normalized < 0 || normalized >= length {
+		return 0, pathError(keys, "index %d out of range [%d, %d)", index, -length, length)
+	}
+
+	return normalized, nil
+}
+
+// Замена содержимого объекта на месте на содержимое content с уведомлением подписчиков.
+//
+// Указатели на объект остаются корректными.
+func (j *Nested) replace(content *Nested) {
+	previous := j.snapshot()
+
+	j.isValue = content.isValue
+	j.isArray = content.isArray
+	j.nested = content.nested
+	j.order = content.order
+	j.array = content.array
+	j.value = content.value
+	j.notify(ChangeSet, previous, j)
 }
 
 // Создание объекта из JSON-строки.
@@ -788,19 +1003,20 @@ func FromJSONString(nested string) *Nested {
 		return FromObject(arrayObject)
 	}
 
-	result := Nested{isValue: true}
+	return &Nested{isValue: true, value: parseScalar(nested)}
+}
 
-	if value, err := strconv.ParseInt(nested, 10, 32); err == nil {
-		result.value = int(value)
-	} else if value, err := strconv.ParseFloat(nested, 32); err == nil {
-		result.value = value
-	} else if value, err := strconv.ParseBool(nested); err == nil {
-		result.value = value
-	} else {
-		result.value = nested
+// Определение типа скалярного значения по строке (в порядке проверки) int, float64, bool, string.
+func parseScalar(s string) any {
+	if value, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return int(value)
+	} else if value, err := strconv.ParseFloat(s, 64); err == nil {
+		return value
+	} else if value, err := strconv.ParseBool(s); err == nil {
+		return value
 	}
 
-	return &result
+	return s
 }
 
 // Рекурсивная функция конвертации интерфейса в Nested.
@@ -1014,3 +1230,188 @@ func (j *Nested) ToObject() any {
 func Equals(a, b *Nested) bool {
 	return reflect.DeepEqual(a.ToObject(), b.ToObject())
 }
+
+// Получение ключей словаря в алфавитном порядке.
+func sortedKeys[T any](nested map[string]T) []string {
+	keys := make([]string, 0, len(nested))
+	for k := range nested {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Ключи словаря в порядке добавления через Set.
+//
+// Ключи, порядок добавления которых неизвестен (например, после [FromObject] или [Nested.SetMap]),
+// следуют за ними в отсортированном порядке.
+func (j *Nested) orderedKeys() []string {
+	keys := make([]string, 0, len(j.nested))
+	ordered := make(map[string]bool, len(j.order))
+
+	for _, k := range j.order {
+		if _, ok := j.nested[k]; ok && !ordered[k] {
+			keys = append(keys, k)
+			ordered[k] = true
+		}
+	}
+
+	for _, k := range sortedKeys(j.nested) {
+		if !ordered[k] {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// Удаление ключа из порядка добавления ключей словаря.
+func (j *Nested) removeOrder(key string) {
+	if index := slices.Index(j.order, key); index >= 0 {
+		j.order = slices.Delete(j.order, index, index+1)
+	}
+}
+
+// Получение вложенного объекта по ключу словаря или индексу массива в виде строки.
+//
+// Возвращает nil, если объекта нет.
+func (j *Nested) child(key string) *Nested {
+	if j.IsValue() {
+		return nil
+	}
+
+	if j.IsArray() {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(j.array) {
+			return nil
+		}
+
+		return j.array[index]
+	}
+
+	return j.nested[key]
+}
+
+// Получение вложенного объекта по пути из ключей словарей и индексов массивов.
+//
+// Возвращает nil, если объекта нет.
+func (j *Nested) lookup(path ...string) *Nested {
+	current := j
+
+	for _, key := range path {
+		if current == nil {
+			return nil
+		}
+
+		current = current.child(key)
+	}
+
+	return current
+}
+
+// Рекурсивное копирование объекта.
+//
+// Скалярные значения копируются по значению интерфейса, поэтому, например, срезы байт остаются общими.
+// Копия не наследует режим отслеживания изменений. Для нулевого указателя возвращается nil.
+func (j *Nested) Clone() *Nested {
+	if j == nil {
+		return nil
+	}
+
+	result, _ := j.copyWith(func(_ string, element *Nested) (*Nested, bool, error) {
+		return element.Clone(), true, nil
+	})
+
+	return result
+}
+
+// Копирование объекта с копированием вложенных объектов через f.
+//
+// Общая основа для [Nested.Clone] и функций, возвращающих измененные копии. Для каждого элемента массива
+// и значения по ключу вызывается f с ключом (для элементов массива - индексом в виде строки);
+// f возвращает копию и признак, что ее нужно сохранить, элементы массива без сохранения удаляются со сдвигом.
+// Ошибка f прерывает копирование.
+func (j *Nested) copyWith(f func(key string, element *Nested) (*Nested, bool, error)) (*Nested, error) {
+	array, nested, err := copyChildren(j.array, j.nested, f)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Nested{isValue: j.isValue, isArray: j.isArray, nested: nested, array: array, value: j.value}
+
+	if nested != nil {
+		result.order = slices.DeleteFunc(slices.Clone(j.order), func(k string) bool {
+			_, ok := nested[k]
+
+			return !ok
+		})
+	}
+
+	return result, nil
+}
+
+// Копирование элементов массива и словаря одного уровня через f, см. [Nested.copyWith].
+//
+// Нулевые срез и словарь остаются нулевыми. Используется и для преобразования между [Nested] и [Frozen].
+func copyChildren[S, D any](array []S, nested map[string]S, f func(key string, element S) (D, bool, error)) ([]D, map[string]D, error) {
+	var resultArray []D
+	if array != nil {
+		resultArray = make([]D, 0, len(array))
+
+		for index, element := range array {
+			copied, keep, err := f(strconv.Itoa(index), element)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if keep {
+				resultArray = append(resultArray, copied)
+			}
+		}
+	}
+
+	var resultNested map[string]D
+	if nested != nil {
+		resultNested = make(map[string]D, len(nested))
+
+		for k, element := range nested {
+			copied, keep, err := f(k, element)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if keep {
+				resultNested[k] = copied
+			}
+		}
+	}
+
+	return resultArray, resultNested, nil
+}
+
+// Ошибка с цепочкой ключей до объекта в формате "a.b: message".
+//
+// Сообщение форматируется через fmt.Errorf, поэтому ошибка, переданная через %w, доступна для errors.Is.
+func pathError(path []string, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if len(path) == 0 {
+		return err
+	}
+
+	return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+}
+
+// Ошибка для объекта по цепочке ключей path, если он не является объектом ключ-значение.
+func nestedError(j *Nested, path []string) error {
+	if j.IsValue() {
+		return pathError(path, "is value")
+	}
+
+	if j.IsArray() {
+		return pathError(path, "is array")
+	}
+
+	return nil
+}
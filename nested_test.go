@@ -1,6 +1,7 @@
 package nested
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -1342,6 +1343,161 @@ func Test_ArrayDelete(t *testing.T) {
 	}
 }
 
+func Test_ArrayGet(t *testing.T) {
+	nested := testNested()
+
+	element, err := nested.ArrayGet(0, "array")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 142, element.value)
+	}
+
+	element, err = nested.ArrayGet(-1, "array")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "string in array", element.value)
+	}
+
+	_, err = nested.ArrayGet(2, "array")
+	assert.EqualError(t, err, "array: index 2 out of range [-2, 2)")
+
+	_, err = nested.ArrayGet(-3, "array")
+	assert.EqualError(t, err, "array: index -3 out of range [-2, 2)")
+
+	_, err = nested.ArrayGet(0, "value")
+	assert.EqualError(t, err, "value: is value")
+
+	_, err = nested.ArrayGet(0)
+	assert.EqualError(t, err, "is nested")
+
+	array := FromJSONString(`[]`)
+
+	_, err = array.ArrayGet(0)
+	assert.EqualError(t, err, "index 0 out of range [0, 0)")
+}
+
+func Test_ArrayInsert(t *testing.T) {
+	nested := FromJSONString(`{"array": [1, 2, 3]}`)
+
+	inserts := []struct {
+		index    int
+		value    any
+		expected string
+	}{
+		{0, "a", `{"array":["a",1,2,3]}`},
+		{2, "b", `{"array":["a",1,"b",2,3]}`},
+		{5, "c", `{"array":["a",1,"b",2,3,"c"]}`},
+		{-1, "d", `{"array":["a",1,"b",2,3,"c","d"]}`},
+		{-2, "e", `{"array":["a",1,"b",2,3,"c","e","d"]}`},
+		{-9, "f", `{"array":["f","a",1,"b",2,3,"c","e","d"]}`},
+	}
+
+	for _, insert := range inserts {
+		err := nested.ArrayInsert(insert.index, &Nested{isValue: true, value: insert.value}, "array")
+		if assert.Nil(t, err) {
+			assert.Equal(t, insert.expected, nested.ToJSONString())
+		}
+	}
+
+	err := nested.ArrayInsert(10, &Nested{}, "array")
+	assert.EqualError(t, err, "array: index 10 out of range [-10, 10)")
+
+	err = nested.ArrayInsert(-11, &Nested{}, "array")
+	assert.EqualError(t, err, "array: index -11 out of range [-10, 10)")
+
+	err = nested.ArrayInsert(0, &Nested{}, "somekey")
+	assert.EqualError(t, err, "key 'somekey' not found")
+
+	empty := FromJSONString(`[]`)
+	assert.Nil(t, empty.ArrayInsert(-1, &Nested{isValue: true, value: 1}))
+	assert.Equal(t, `[1]`, empty.ToJSONString())
+}
+
+func Test_ArraySet(t *testing.T) {
+	nested := testNested()
+
+	array, _ := nested.GetArray("array")
+	old := array[1]
+
+	err := nested.ArraySet(-1, &Nested{isValue: true, value: "replaced"}, "array")
+	if assert.Nil(t, err) {
+		assert.Equal(t, []any{142, "replaced"}, nested.ToObject().(map[string]any)["array"])
+
+		// замененный элемент не очищается
+		assert.Equal(t, "string in array", old.value)
+	}
+
+	err = nested.ArraySet(2, &Nested{}, "array")
+	assert.EqualError(t, err, "array: index 2 out of range [-2, 2)")
+
+	err = nested.ArraySet(0, &Nested{}, "nested", "value")
+	assert.EqualError(t, err, "nested.value: is value")
+}
+
+func Test_ArrayRemoveAt(t *testing.T) {
+	nested := FromJSONString(`[1, {"a": 2}, 3, 4]`)
+
+	removed, err := nested.ArrayRemoveAt(1)
+	if assert.Nil(t, err) {
+		assert.Equal(t, `[1,3,4]`, nested.ToJSONString())
+
+		// удаленный элемент не очищается
+		assert.Equal(t, `{"a":2}`, removed.ToJSONString())
+	}
+
+	removed, err = nested.ArrayRemoveAt(-1)
+	if assert.Nil(t, err) {
+		assert.Equal(t, `[1,3]`, nested.ToJSONString())
+		assert.Equal(t, 4, removed.value)
+	}
+
+	_, err = nested.ArrayRemoveAt(2)
+	assert.EqualError(t, err, "index 2 out of range [-2, 2)")
+
+	_, err = nested.ArrayRemoveAt(-3)
+	assert.EqualError(t, err, "index -3 out of range [-2, 2)")
+
+	_, err = FromJSONString(`{"a": 1}`).ArrayRemoveAt(0, "a")
+	assert.EqualError(t, err, "a: is value")
+}
+
+func Test_ArrayMove(t *testing.T) {
+	nested := FromJSONString(`{"a": {"list": [0, 1, 2, 3, 4]}}`)
+
+	moves := []struct {
+		from, to int
+		expected string
+	}{
+		{0, 2, `[1,2,0,3,4]`},
+		{4, 0, `[4,1,2,0,3]`},
+		{-1, -2, `[4,1,2,3,0]`},
+		{1, 1, `[4,1,2,3,0]`},
+		{0, -1, `[1,2,3,0,4]`},
+	}
+
+	for _, move := range moves {
+		if assert.Nil(t, nested.ArrayMove(move.from, move.to, "a", "list")) {
+			list, _ := nested.Get("a", "list")
+			assert.Equal(t, move.expected, list.ToJSONString())
+		}
+	}
+
+	err := nested.ArrayMove(5, 0, "a", "list")
+	assert.EqualError(t, err, "a.list: index 5 out of range [-5, 5)")
+
+	err = nested.ArrayMove(0, -6, "a", "list")
+	assert.EqualError(t, err, "a.list: index -6 out of range [-5, 5)")
+
+	err = nested.ArrayMove(0, 1, "a")
+	assert.EqualError(t, err, "a: is nested")
+
+	events := []string{}
+	nested.Subscribe(func(event ChangeEvent) {
+		events = append(events, string(event.Op)+" "+strings.Join(event.Path, "."))
+	})
+
+	assert.Nil(t, nested.ArrayMove(0, 3, "a", "list"))
+	assert.Equal(t, []string{"remove a.list.0", "add a.list.3"}, events)
+}
+
 func Test_FromObject(t *testing.T) {
 	// детальное использование тестируется в [Test_FromJSONString].
 	nested := FromObject(42)
@@ -2359,3 +2515,35 @@ func Test_Equals(t *testing.T) {
 		assert.Equal(t, test.expected, Equals(&test.a, &test.b))
 	}
 }
+
+func Test_PathErrors(t *testing.T) {
+	nested := FromJSONString(`{"a": {"b": 1, "arr": [1]}}`)
+	frozen := nested.Freeze()
+
+	cases := []struct {
+		keys     []string
+		expected string
+	}{
+		{[]string{"a", "b", "c"}, "a.b: is value"},
+		{[]string{"a", "b", "c", "d"}, "a.b: is value"},
+		{[]string{"a", "x"}, "a: key 'x' not found"},
+		{[]string{"a", "x", "y"}, "a: key 'x' not found"},
+		{[]string{"a", "arr", "0", "1"}, "a.arr: is array"},
+		{[]string{"x"}, "key 'x' not found"},
+	}
+
+	// ошибки Nested и Frozen совпадают
+	for _, c := range cases {
+		_, err := nested.Get(c.keys...)
+		assert.EqualError(t, err, c.expected)
+
+		_, err = frozen.Get(c.keys...)
+		assert.EqualError(t, err, c.expected)
+	}
+
+	assert.EqualError(t, nested.SetValue(2, "a", "b", "c", "d"), "a.b: is value")
+	assert.EqualError(t, nested.SetValue(2, "a", "arr", "0"), "a.arr: is array")
+
+	_, err := frozen.WithValue(2, "a", "b", "c", "d")
+	assert.EqualError(t, err, "a.b: is value")
+}
@@ -0,0 +1,232 @@
+package nested
+
+import (
+	"slices"
+	"strconv"
+)
+
+// Вид изменения объекта.
+type ChangeOp string
+
+const (
+	ChangeSet    ChangeOp = "set"    // сохранение объекта по ключу или замена содержимого объекта
+	ChangeDelete ChangeOp = "delete" // удаление ключа
+	ChangeAdd    ChangeOp = "add"    // добавление элемента в массив или объекта, созданного по отсутствующему ключу
+	ChangeRemove ChangeOp = "remove" // удаление элемента из массива
+)
+
+// Событие изменения объекта.
+type ChangeEvent struct {
+	Path []string // цепочка ключей до измененного объекта от корня, для элементов массива - индекс в виде строки
+	Op   ChangeOp
+	Old  *Nested // предыдущий объект, nil для добавленных ключей и элементов
+	New  *Nested // новый объект, nil для удаленных ключей и элементов
+}
+
+// Обработчик событий изменения объекта.
+type ChangeListener func(event ChangeEvent)
+
+// Подписчики на изменения объекта и его положение в дереве.
+//
+// Общий для всех объектов дерева набор подписчиков хранится в observer,
+// поэтому изменения через вложенные объекты, полученные через Get, тоже доставляются подписчикам.
+// Путь объекта от корня вычисляется по родительским объектам при событии, поэтому смещение
+// элементов массива не требует обновления вложенных объектов.
+type observation struct {
+	observer *observer
+	parent   *Nested // nil для корня
+	key      string  // ключ в родительском объекте, для элементов массива не используется
+}
+
+// Подписчики на изменения дерева объектов.
+type observer struct {
+	listeners map[int]*listener
+	nextID    int
+}
+
+// Подписчик на изменения по цепочке ключей.
+type listener struct {
+	path     []string
+	listener ChangeListener
+}
+
+// Включение режима отслеживания изменений для объекта и всех вложенных в него.
+//
+// Повторный вызов ничего не делает. Обычно вызывать не требуется, так как режим включается
+// при первой подписке через [Nested.Subscribe].
+func (j *Nested) Observe() {
+	if j.observation != nil {
+		return
+	}
+
+	attachObservation(j, &observer{listeners: map[int]*listener{}}, nil, "")
+}
+
+// Подписка на изменения объекта по цепочке ключей.
+//
+// Включает режим отслеживания изменений (см. [Nested.Observe]), в котором функции Set, SetValue, SetMap,
+// SetArray, Delete, Clear, ArrayAdd, ArrayDelete и производные от них уведомляют подписчиков о выполненных изменениях.
+// Изменения доставляются и при вызове функций у вложенных объектов, полученных через Get.
+// Если Set создает промежуточные объекты по отсутствующим ключам, доставляется одно событие ChangeAdd
+// для самого верхнего созданного объекта, уже содержащего сохраненный объект.
+//
+// Подписчик получает события, путь которых начинается с цепочки ключей, а также события для объектов,
+// содержащих цепочку ключей (например, замену или удаление родительского объекта).
+// Без ключей подписчик получает все события. Пути событий и цепочка ключей отсчитываются от корня,
+// для которого включен режим, поэтому подписку на вложенный объект следует делать после включения режима у корня.
+//
+// События доставляются синхронно после изменения. Для ArrayDelete и Clear событие доставляется до очистки
+// удаленных объектов функцией Clear, поэтому Old доступен только во время вызова подписчика.
+// Объекты, сохраненные в нескольких местах дерева, получают путь последнего сохранения.
+//
+// Возвращает функцию отмены подписки.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"user": {"name": "Alice"}}`)
+//
+//	nested.Subscribe(func(event ChangeEvent) {
+//		fmt.Println(event.Op, event.Path)
+//	}, "user")
+//
+//	user, _ := nested.Get("user")
+//	user.SetValue("Bob", "name") // set [user name]
+func (j *Nested) Subscribe(changeListener ChangeListener, keys ...string) func() {
+	j.Observe()
+
+	observer := j.observation.observer
+
+	id := observer.nextID
+	observer.nextID++
+	observer.listeners[id] = &listener{
+		path:     append(j.observedPath(), keys...),
+		listener: changeListener,
+	}
+
+	return func() {
+		delete(observer.listeners, id)
+	}
+}
+
+// Уведомление подписчиков об изменении объекта по цепочке ключей относительно текущего.
+//
+// Удаленный объект отключается от отслеживания, новый - подключается с соответствующим путем.
+func (j *Nested) notify(op ChangeOp, previous, current *Nested, keys ...string) {
+	j.resetHash()
+
+	if j.observation == nil {
+		return
+	}
+
+	if previous != nil && previous != current {
+		detachObservation(previous)
+	}
+
+	if current != nil {
+		if len(keys) == 0 {
+			attachObservation(current, j.observation.observer, j.observation.parent, j.observation.key)
+		} else {
+			attachObservation(current, j.observation.observer, j, keys[0])
+		}
+	}
+
+	observer := j.observation.observer
+	if len(observer.listeners) == 0 {
+		return
+	}
+
+	path := append(j.observedPath(), keys...)
+
+	ids := make([]int, 0, len(observer.listeners))
+	for id := range observer.listeners {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		l, ok := observer.listeners[id]
+		if !ok {
+			continue
+		}
+
+		length := min(len(l.path), len(path))
+		if slices.Equal(l.path[:length], path[:length]) {
+			l.listener(ChangeEvent{Path: slices.Clone(path), Op: op, Old: previous, New: current})
+		}
+	}
+}
+
+// Путь объекта от корня, для которого включен режим отслеживания.
+//
+// Индексы элементов массивов определяются по текущему положению в родительском массиве.
+func (j *Nested) observedPath() []string {
+	path := []string{}
+
+	for current := j; current.observation != nil && current.observation.parent != nil; current = current.observation.parent {
+		parent := current.observation.parent
+
+		if parent.isArray {
+			path = append(path, strconv.Itoa(slices.Index(parent.array, current)))
+		} else {
+			path = append(path, current.observation.key)
+		}
+	}
+
+	slices.Reverse(path)
+
+	return path
+}
+
+// Копия полей объекта без вложенных копий для передачи прежнего состояния в событии.
+func (j *Nested) snapshot() *Nested {
+	if j.observation == nil {
+		return nil
+	}
+
+	return &Nested{
+		isValue:     j.isValue,
+		isArray:     j.isArray,
+		nested:      j.nested,
+		order:       j.order,
+		array:       j.array,
+		value:       j.value,
+		observation: j.observation,
+	}
+}
+
+// Рекурсивное подключение объекта и всех вложенных к отслеживанию.
+//
+// parent и key - родительский объект и ключ в нем, nil и "" для корня.
+func attachObservation(j *Nested, observer *observer, parent *Nested, key string) {
+	if j == nil {
+		return
+	}
+
+	j.observation = &observation{observer: observer, parent: parent, key: key}
+
+	for _, element := range j.array {
+		attachObservation(element, observer, j, "")
+	}
+
+	for k, element := range j.nested {
+		attachObservation(element, observer, j, k)
+	}
+}
+
+// Рекурсивное отключение объекта и всех вложенных от отслеживания.
+func detachObservation(j *Nested) {
+	if j == nil || j.observation == nil {
+		return
+	}
+
+	j.observation = nil
+
+	for _, element := range j.array {
+		detachObservation(element)
+	}
+
+	for _, element := range j.nested {
+		detachObservation(element)
+	}
+}
@@ -0,0 +1,185 @@
+package nested
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Subscribe(t *testing.T) {
+	nested := FromJSONString(`{"user": {"name": "Alice", "roles": ["admin"]}, "count": 1}`)
+
+	all := []ChangeEvent{}
+	nested.Subscribe(func(event ChangeEvent) {
+		all = append(all, event)
+	})
+
+	user := []string{}
+	unsubscribe := nested.Subscribe(func(event ChangeEvent) {
+		user = append(user, string(event.Op)+" "+strings.Join(event.Path, "."))
+	}, "user")
+
+	name := []string{}
+	nested.Subscribe(func(event ChangeEvent) {
+		name = append(name, string(event.Op)+" "+strings.Join(event.Path, "."))
+	}, "user", "name")
+
+	oldName, _ := nested.Get("user", "name")
+
+	assert.Nil(t, nested.SetValue("Bob", "user", "name"))
+
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, []string{"user", "name"}, all[0].Path)
+		assert.Equal(t, ChangeSet, all[0].Op)
+		assert.Same(t, oldName, all[0].Old)
+		assert.Equal(t, &Nested{isValue: true, value: "Bob"}, withoutObservation(all[0].New))
+	}
+
+	// изменения через вложенный объект, полученный через Get
+	userNested, _ := nested.Get("user")
+	assert.Nil(t, userNested.ArrayAddValue("dev", "roles"))
+	assert.Nil(t, userNested.SetValue(30, "age"))
+
+	roles, _ := userNested.Get("roles")
+	assert.Nil(t, roles.ArrayAddValue("ops"))
+	assert.Nil(t, roles.ArrayDelete(func(element *Nested) bool {
+		value, _ := element.GetValue()
+		return value == "admin" || value == "ops"
+	}))
+
+	// пути элементов обновляются после смещения индексов
+	dev, _ := roles.GetArray()
+	assert.Nil(t, dev[0].SetValue("developer"))
+
+	assert.Nil(t, nested.Delete("user", "age"))
+	assert.Nil(t, nested.Delete("user", "missing"))
+	assert.Nil(t, nested.SetValue(2, "count"))
+
+	assert.Equal(t, []string{
+		"set user.name",
+		"add user.roles.1",
+		"set user.age",
+		"add user.roles.2",
+		"remove user.roles.2",
+		"remove user.roles.0",
+		"set user.roles.0",
+		"delete user.age",
+	}, user)
+
+	// замена родительского объекта затрагивает подписчиков вложенных
+	assert.Nil(t, nested.Set(FromJSONString(`{"name": "Carol"}`), "user"))
+	assert.Equal(t, []string{"set user.name", "set user"}, name)
+
+	// объект, замененный целиком, отключается от отслеживания
+	count := len(all)
+	assert.Nil(t, userNested.SetValue("ignored", "name"))
+	assert.Len(t, all, count)
+
+	carol, _ := nested.Get("user")
+	assert.Nil(t, carol.SetValue("Dave", "name"))
+	assert.Equal(t, []string{"set user.name", "set user", "set user.name"}, name)
+
+	unsubscribe()
+	assert.Nil(t, nested.SetValue(1, "user", "id"))
+	assert.Len(t, user, 10)
+
+	// создание промежуточных объектов и замена содержимого без ключей
+	created := []ChangeEvent{}
+	nested.Subscribe(func(event ChangeEvent) {
+		created = append(created, event)
+	}, "a")
+
+	assert.Nil(t, nested.SetValue(1, "a", "b", "c"))
+
+	b, _ := nested.Get("a", "b")
+	assert.Nil(t, b.SetMap(map[string]*Nested{"d": {isValue: true, value: 2}}))
+
+	d, _ := nested.Get("a", "b", "d")
+	assert.Nil(t, d.SetValue(3))
+
+	if assert.Len(t, created, 3) {
+		// одно событие добавления самого верхнего из созданных объектов
+		assert.Equal(t, []string{"a"}, created[0].Path)
+		assert.Equal(t, ChangeAdd, created[0].Op)
+		assert.Nil(t, created[0].Old)
+		a, _ := nested.Get("a")
+		assert.Same(t, a, created[0].New)
+		assert.Equal(t, []string{"a", "b"}, created[1].Path)
+		assert.Same(t, b, created[1].New)
+		assert.Equal(t, map[string]*Nested{"c": {isValue: true, value: 1}}, withoutObservation(created[1].Old).nested)
+		assert.Equal(t, []string{"a", "b", "d"}, created[2].Path)
+		assert.Equal(t, &Nested{isValue: true, value: 2}, withoutObservation(created[2].Old))
+	}
+}
+
+func Test_SubscribeArray(t *testing.T) {
+	nested := FromJSONString(`[1, 2, 3]`)
+
+	events := []string{}
+	lengths := []int{}
+	nested.Subscribe(func(event ChangeEvent) {
+		events = append(events, string(event.Op)+" "+strings.Join(event.Path, "."))
+		lengths = append(lengths, nested.Length())
+	})
+
+	assert.Nil(t, nested.ArrayAddValue(4))
+	assert.Nil(t, nested.ArrayDelete(func(element *Nested) bool {
+		value, _ := element.GetValue()
+		return value.(int)%2 == 0
+	}))
+	assert.Nil(t, nested.SetArray([]*Nested{}))
+	assert.Nil(t, nested.Clear())
+
+	assert.Equal(t, []string{"add 3", "remove 3", "remove 1", "set ", "set "}, events)
+
+	// события доставляются после изменения
+	assert.Equal(t, []int{4, 3, 2, 0, 0}, lengths)
+}
+
+// Рекурсивное удаление данных отслеживания для сравнения объектов в тестах.
+func withoutObservation(j *Nested) *Nested {
+	if j == nil {
+		return nil
+	}
+
+	result := &Nested{isValue: j.isValue, isArray: j.isArray, value: j.value}
+
+	for _, element := range j.array {
+		result.array = append(result.array, withoutObservation(element))
+	}
+
+	if j.nested != nil {
+		result.nested = map[string]*Nested{}
+		for k, element := range j.nested {
+			result.nested[k] = withoutObservation(element)
+		}
+	}
+
+	return result
+}
+
+func Test_ObserveArrayShift(t *testing.T) {
+	nested := FromJSONString(`{"items": [{"v": 1}, {"v": 2}]}`)
+
+	paths := []string{}
+	nested.Subscribe(func(event ChangeEvent) {
+		paths = append(paths, string(event.Op)+" "+strings.Join(event.Path, "."))
+	})
+
+	second, _ := nested.ArrayGet(1, "items")
+	observation := second.nested["v"].observation
+
+	assert.Nil(t, nested.ArrayInsert(0, FromJSONString(`{"v": 0}`), "items"))
+
+	// смещение индексов не обновляет вложенные объекты
+	assert.Same(t, observation, second.nested["v"].observation)
+
+	assert.Nil(t, second.SetValue(3, "v"))
+
+	_, err := nested.ArrayRemoveAt(0, "items")
+	assert.Nil(t, err)
+	assert.Nil(t, second.SetValue(4, "v"))
+
+	assert.Equal(t, []string{"add items.0", "set items.2.v", "remove items.0", "set items.1.v"}, paths)
+}
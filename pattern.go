@@ -0,0 +1,122 @@
+package nested
+
+import (
+	"slices"
+	"strings"
+)
+
+// Ключ-шаблон, совпадающий с любым ключом объекта или индексом элемента массива в шаблонах путей
+// ([Nested.Pick], [Nested.Omit], [RedactRule], [StreamParser]).
+const Wildcard = "*"
+
+// Разделитель ключей в строковых шаблонах путей.
+const patternSeparator = '.'
+
+// Экранирующий символ в строковых шаблонах путей.
+const patternEscape = '\\'
+
+// Ключ шаблона пути.
+type patternKey struct {
+	key      string
+	wildcard bool
+}
+
+// Шаблон пути - цепочка ключей, где ключ-шаблон совпадает с любым ключом.
+type pathPattern []patternKey
+
+// Шаблон из цепочки ключей, где [Wildcard] - ключ-шаблон.
+func keysPattern(keys []string) pathPattern {
+	pattern := make(pathPattern, 0, len(keys))
+	for _, key := range keys {
+		pattern = append(pattern, patternKey{key: key, wildcard: key == Wildcard})
+	}
+
+	return pattern
+}
+
+// Разбор строкового шаблона пути.
+//
+// Ключи разделяются ".", ключ "*" совпадает с любым ключом. Символ после "\" входит в ключ как есть,
+// поэтому "a\.b" - это ключ "a.b", а "\*" - ключ "*". Пустая строка - шаблон корневого объекта.
+func parsePattern(path string) pathPattern {
+	pattern := pathPattern{}
+	if path == "" {
+		return pattern
+	}
+
+	var key strings.Builder
+	escaped, literal := false, false
+
+	for _, r := range path {
+		switch {
+		case escaped:
+			key.WriteRune(r)
+			escaped, literal = false, true
+		case r == patternEscape:
+			escaped = true
+		case r == patternSeparator:
+			pattern = append(pattern, patternKey{key: key.String(), wildcard: !literal && key.String() == Wildcard})
+			key.Reset()
+			literal = false
+		default:
+			key.WriteRune(r)
+		}
+	}
+
+	// "\" в конце строки входит в ключ
+	if escaped {
+		key.WriteRune(patternEscape)
+	}
+
+	return append(pattern, patternKey{key: key.String(), wildcard: !literal && key.String() == Wildcard})
+}
+
+// Разбор строковых шаблонов путей, см. [parsePattern].
+func parsePatterns(paths []string) []pathPattern {
+	patterns := make([]pathPattern, 0, len(paths))
+	for _, path := range paths {
+		patterns = append(patterns, parsePattern(path))
+	}
+
+	return patterns
+}
+
+// Проверка, что начало шаблона совпадает с путем.
+func (p pathPattern) matchPrefix(path []string) bool {
+	if len(p) < len(path) {
+		return false
+	}
+
+	for index, key := range path {
+		if !p[index].wildcard && p[index].key != key {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Проверка совпадения пути с шаблоном целиком.
+func (p pathPattern) match(path []string) bool {
+	return len(p) == len(path) && p.matchPrefix(path)
+}
+
+// Оставшиеся части шаблонов, первый ключ которых совпадает с key.
+func descendPatterns(patterns []pathPattern, key string) []pathPattern {
+	var result []pathPattern
+
+	for _, pattern := range patterns {
+		if pattern.matchPrefix([]string{key}) {
+			result = append(result, pattern[1:])
+		}
+	}
+
+	return result
+}
+
+// Проверка, что один из шаблонов полностью пройден.
+func patternsMatch(patterns []pathPattern) bool {
+	return slices.ContainsFunc(patterns, func(pattern pathPattern) bool {
+		return len(pattern) == 0
+	})
+}
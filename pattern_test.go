@@ -0,0 +1,32 @@
+package nested
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parsePattern(t *testing.T) {
+	assert.Equal(t, pathPattern{}, parsePattern(""))
+	assert.Equal(t, pathPattern{{key: "a"}, {key: "*", wildcard: true}, {key: "0"}}, parsePattern("a.*.0"))
+	assert.Equal(t, pathPattern{{key: "a.b"}, {key: "c"}}, parsePattern(`a\.b.c`))
+	assert.Equal(t, pathPattern{{key: "*"}, {key: `a\`}}, parsePattern(`\*.a\\`))
+	assert.Equal(t, pathPattern{{key: ""}, {key: `a\`}}, parsePattern(`.a\`))
+	assert.Equal(t, pathPattern{{key: "*", wildcard: true}, {key: ""}}, parsePattern("*."))
+}
+
+func Test_pathPattern(t *testing.T) {
+	pattern := parsePattern("a.*.c")
+
+	assert.True(t, pattern.match([]string{"a", "b", "c"}))
+	assert.False(t, pattern.match([]string{"a", "b"}))
+	assert.False(t, pattern.match([]string{"a", "b", "d"}))
+	assert.True(t, pattern.matchPrefix([]string{"a", "b"}))
+	assert.True(t, pattern.matchPrefix([]string{}))
+	assert.False(t, pattern.matchPrefix([]string{"b"}))
+	assert.False(t, pattern.matchPrefix([]string{"a", "b", "c", "d"}))
+
+	assert.True(t, keysPattern([]string{"a", Wildcard}).match([]string{"a", "*"}))
+	assert.False(t, parsePattern(`a.\*`).match([]string{"a", "b"}))
+	assert.True(t, parsePattern(`a.\*`).match([]string{"a", "*"}))
+}
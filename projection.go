@@ -0,0 +1,101 @@
+package nested
+
+// Новый объект, содержащий только объекты по шаблонам путей.
+//
+// Шаблон - цепочка ключей через ".", где [Wildcard] совпадает с любым ключом объекта или любым элементом
+// массива, а элементы массивов также выбираются по индексу. Символ после "\" входит в ключ как есть:
+// "a\.b" - ключ "a.b", "\*" - ключ "*". Найденные объекты копируются целиком
+// вместе с родительскими объектами, из массивов выбираются только элементы с совпадениями (со сдвигом).
+// Если совпадений нет, возвращается пустой объект того же вида (для значения - пустой объект с ключами).
+// Исходный объект не изменяется.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"users": [{"name": "alice", "password": "secret"}], "total": 1}`)
+//
+//	nested.Pick("users.*.name") // {"users": [{"name": "alice"}]}
+func (j *Nested) Pick(paths ...string) *Nested {
+	if j == nil {
+		return nil
+	}
+
+	if result := pick(j, parsePatterns(paths)); result != nil {
+		return result
+	}
+
+	if j.isArray {
+		return &Nested{isArray: true, array: []*Nested{}}
+	}
+
+	return &Nested{nested: map[string]*Nested{}}
+}
+
+// Новый объект без объектов по шаблонам путей.
+//
+// Шаблоны задаются так же, как для [Nested.Pick]. Элементы массивов удаляются со сдвигом,
+// при этом индексы в шаблонах соответствуют исходному объекту. Если шаблон совпадает с исходным объектом
+// (пустая строка), возвращается nil. Исходный объект не изменяется.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"users": [{"name": "alice", "password": "secret"}], "total": 1}`)
+//
+//	nested.Omit("users.*.password", "total") // {"users": [{"name": "alice"}]}
+func (j *Nested) Omit(paths ...string) *Nested {
+	patterns := parsePatterns(paths)
+	if patternsMatch(patterns) {
+		return nil
+	}
+
+	return omit(j, patterns)
+}
+
+// Рекурсивное копирование объектов по шаблонам, nil - если совпадений нет.
+func pick(j *Nested, patterns []pathPattern) *Nested {
+	if j == nil {
+		return nil
+	}
+
+	if patternsMatch(patterns) {
+		return j.Clone()
+	}
+
+	if j.isValue {
+		return nil
+	}
+
+	result, _ := j.copyWith(func(key string, element *Nested) (*Nested, bool, error) {
+		sub := descendPatterns(patterns, key)
+		if len(sub) == 0 {
+			return nil, false, nil
+		}
+
+		picked := pick(element, sub)
+
+		return picked, picked != nil, nil
+	})
+
+	if result.Length() == 0 {
+		return nil
+	}
+
+	return result
+}
+
+// Рекурсивное копирование объектов без совпадающих с шаблонами.
+func omit(j *Nested, patterns []pathPattern) *Nested {
+	if j == nil || len(patterns) == 0 {
+		return j.Clone()
+	}
+
+	result, _ := j.copyWith(func(key string, element *Nested) (*Nested, bool, error) {
+		sub := descendPatterns(patterns, key)
+		if patternsMatch(sub) {
+			return nil, false, nil
+		}
+
+		return omit(element, sub), true, nil
+	})
+
+	return result
+}
@@ -0,0 +1,74 @@
+package nested
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Pick(t *testing.T) {
+	source := `{
+		"users": [
+			{"name": "alice", "password": "a", "roles": ["admin"]},
+			{"password": "b"},
+			{"name": "carol", "password": "c"}
+		],
+		"meta": {"total": 3, "page": 1},
+		"total": 3
+	}`
+	nested := FromJSONString(source)
+
+	assert.Equal(t, `{"users":[{"name":"alice"},{"name":"carol"}]}`, nested.Pick("users.*.name").ToJSONString())
+	assert.Equal(t, `{"meta":{"total":3},"total":3}`, nested.Pick("total", "meta.total").ToJSONString())
+	assert.Equal(t, `{"meta":{"page":1,"total":3},"users":[{"password":"b"}]}`, nested.Pick("meta", "users.1.password").ToJSONString())
+	assert.Equal(t, `{"meta":{"page":1,"total":3}}`, nested.Pick("*.page", "meta").ToJSONString())
+	assert.Equal(t, `{"users":[{"roles":["admin"]}]}`, nested.Pick("users.*.roles.*").ToJSONString())
+	assert.Equal(t, FromJSONString(source).ToJSONString(), nested.Pick("").ToJSONString())
+
+	// без совпадений - пустой объект того же вида
+	assert.Equal(t, `{}`, nested.Pick("missing", "total.value").ToJSONString())
+	assert.Equal(t, `[]`, FromJSONString(`[1, 2]`).Pick("a").ToJSONString())
+	assert.Equal(t, `[2]`, FromJSONString(`[1, 2]`).Pick("1").ToJSONString())
+
+	// экранирование разделителя и ключа-шаблона
+	dotted := FromJSONString(`{"a.b": {"c": 1, "*": 2}, "a": {"b": 3}}`)
+	assert.Equal(t, `{"a.b":{"c":1}}`, dotted.Pick(`a\.b.c`).ToJSONString())
+	assert.Equal(t, `{"a.b":{"*":2}}`, dotted.Pick(`a\.b.\*`).ToJSONString())
+	assert.Equal(t, `{"a":{"b":3},"a.b":{"c":1}}`, dotted.Omit(`a\.b.\*`).ToJSONString())
+
+	// результат не разделяет объекты с исходным
+	picked := nested.Pick("meta")
+	assert.Nil(t, picked.SetValue(10, "meta", "total"))
+	assert.Equal(t, FromJSONString(source).ToJSONString(), nested.ToJSONString())
+}
+
+func Test_Omit(t *testing.T) {
+	source := `{
+		"users": [
+			{"name": "alice", "password": "a", "tokens": ["x", "y"]},
+			{"name": "bob", "password": "b"}
+		],
+		"internal": {"debug": true},
+		"total": 2
+	}`
+	nested := FromJSONString(source)
+
+	assert.Equal(t,
+		`{"total":2,"users":[{"name":"alice","tokens":["x","y"]},{"name":"bob"}]}`,
+		nested.Omit("users.*.password", "internal").ToJSONString())
+	assert.Equal(t,
+		`{"internal":{"debug":true},"total":2,"users":[{"name":"bob","password":"b"}]}`,
+		nested.Omit("users.0").ToJSONString())
+	assert.Equal(t,
+		`{"internal":{},"total":2,"users":[{"name":"alice","password":"a","tokens":[]},{"name":"bob","password":"b"}]}`,
+		nested.Omit("*.*.tokens.*", "internal.*").ToJSONString())
+	assert.Equal(t, FromJSONString(source).ToJSONString(), nested.Omit("missing", "total.value").ToJSONString())
+	assert.Equal(t, FromJSONString(source).ToJSONString(), nested.Omit().ToJSONString())
+	assert.Nil(t, nested.Omit(""))
+
+	// результат не разделяет объекты с исходным
+	omitted := nested.Omit("internal")
+	users, _ := omitted.GetArray("users")
+	assert.Nil(t, users[0].SetValue("eve", "name"))
+	assert.Equal(t, FromJSONString(source).ToJSONString(), nested.ToJSONString())
+}
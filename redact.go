@@ -0,0 +1,289 @@
+package nested
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Маска по умолчанию для скрываемых значений.
+const DefaultRedactMask = "***"
+
+// Регулярные выражения для часто скрываемых значений.
+var (
+	// Адрес электронной почты.
+	RedactEmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// Номер банковской карты из 13-19 цифр, возможно разделенных пробелами или дефисами.
+	// Совпадения дополнительно проверяются по алгоритму Луна, остальные последовательности цифр не скрываются.
+	RedactCardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+// Правило скрытия значений.
+//
+// Объект скрывается целиком, если его путь совпадает с одним из шаблонов Paths (в формате [Nested.Pick])
+// или его ключ совпадает с одним из шаблонов Keys. Шаблоны ключей сравниваются без учета регистра,
+// "*" совпадает с любой последовательностью символов, например "*token*" или "password".
+// В значениях остальных объектов скрываются части, совпадающие с Values. Числа проверяются
+// в каноническом виде (см. [Nested.ToCanonicalJSON]) и при совпадении заменяются строкой.
+//
+// Скрытые объекты и части строк заменяются на Mask (по умолчанию [DefaultRedactMask]), а если задан HashKey -
+// на строку "hmac:" с первыми 8 байтами HMAC-SHA256 скрытого значения в каноническом виде с ключом HashKey
+// в шестнадцатеричном виде. Это позволяет сопоставлять одинаковые значения в логах, не раскрывая их,
+// а без знания ключа нельзя подобрать значение по хешу перебором.
+type RedactRule struct {
+	Paths   []string
+	Keys    []string
+	Values  []*regexp.Regexp
+	Mask    string
+	HashKey []byte
+}
+
+// Новый объект со скрытыми значениями по правилам.
+//
+// Правила применяются сверху вниз: объект заменяется по первому подходящему правилу,
+// и вложенные в него объекты не обходятся. Исходный объект не изменяется.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"user": "alice@example.com", "auth": {"Access_Token": "abc"}}`)
+//
+//	nested.Redact(
+//		RedactRule{Keys: []string{"*token*", "password"}},
+//		RedactRule{Values: []*regexp.Regexp{RedactEmailPattern}, HashKey: key},
+//	)
+//	// {"auth": {"Access_Token": "***"}, "user": "hmac:..."}
+func (j *Nested) Redact(rules ...RedactRule) *Nested {
+	return newRedactor(rules).redact(j, []string{}, false)
+}
+
+// Представление объекта для логирования через slog со скрытыми значениями.
+//
+// Скрытие выполняется только при записи в лог. Объекты ключ-значение записываются группами
+// с ключами в алфавитном порядке, массивы и значения - как есть.
+//
+// Пример:
+//
+//	slog.Info("request", "body", nested.Redacted(RedactRule{Keys: []string{"password"}}))
+type RedactedView struct {
+	nested *Nested
+	rules  []RedactRule
+}
+
+// Создание представления объекта для логирования со скрытыми значениями по правилам (см. [Nested.Redact]).
+func (j *Nested) Redacted(rules ...RedactRule) RedactedView {
+	return RedactedView{nested: j, rules: rules}
+}
+
+// Значение для slog.
+func (v RedactedView) LogValue() slog.Value {
+	return logValue(v.nested.Redact(v.rules...))
+}
+
+// Правила скрытия с разобранными шаблонами.
+type redactor struct {
+	rules []RedactRule
+	paths [][]pathPattern // шаблоны путей для каждого правила
+	keys  [][]string      // шаблоны ключей в нижнем регистре для каждого правила
+}
+
+func newRedactor(rules []RedactRule) *redactor {
+	r := &redactor{rules: rules}
+
+	for _, rule := range rules {
+		keys := make([]string, 0, len(rule.Keys))
+		for _, key := range rule.Keys {
+			keys = append(keys, strings.ToLower(key))
+		}
+
+		r.paths = append(r.paths, parsePatterns(rule.Paths))
+		r.keys = append(r.keys, keys)
+	}
+
+	return r
+}
+
+// Рекурсивное копирование объекта со скрытием значений.
+//
+// keyed - объект является значением по ключу (последнему в path), а не элементом массива.
+func (r *redactor) redact(j *Nested, path []string, keyed bool) *Nested {
+	if j == nil {
+		return nil
+	}
+
+	if rule, ok := r.match(path, keyed); ok {
+		return &Nested{isValue: true, value: r.replacement(rule, j)}
+	}
+
+	result, _ := j.copyWith(func(key string, element *Nested) (*Nested, bool, error) {
+		return r.redact(element, append(slices.Clone(path), key), !j.isArray), true, nil
+	})
+
+	if !j.isValue {
+		return result
+	}
+
+	if s, ok := j.value.(string); ok {
+		result.value = r.redactString(s)
+	} else if s, ok := formatNumber(j.value); ok {
+		if redacted := r.redactString(s); redacted != s {
+			result.value = redacted
+		}
+	}
+
+	return result
+}
+
+// Поиск первого правила, скрывающего объект целиком.
+func (r *redactor) match(path []string, keyed bool) (int, bool) {
+	for index := range r.rules {
+		for _, pattern := range r.paths[index] {
+			if pattern.match(path) {
+				return index, true
+			}
+		}
+
+		if !keyed {
+			continue
+		}
+
+		key := strings.ToLower(path[len(path)-1])
+		for _, pattern := range r.keys[index] {
+			if matchKey(pattern, key) {
+				return index, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// Скрытие частей строки по регулярным выражениям всех правил.
+func (r *redactor) redactString(s string) string {
+	for index, rule := range r.rules {
+		for _, pattern := range rule.Values {
+			s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+				if pattern == RedactCardNumberPattern && !validLuhn(match) {
+					return match
+				}
+
+				return r.replacement(index, &Nested{isValue: true, value: match})
+			})
+		}
+	}
+
+	return s
+}
+
+// Замена для скрываемого объекта.
+//
+// Если значение нельзя записать в каноническом виде (например, NaN), используется маска.
+func (r *redactor) replacement(index int, j *Nested) string {
+	rule := r.rules[index]
+
+	if len(rule.HashKey) > 0 {
+		buffer := &bytes.Buffer{}
+		if err := writeCanonicalNestedWith(buffer, j, writeHashValue); err == nil {
+			mac := hmac.New(sha256.New, rule.HashKey)
+			mac.Write(buffer.Bytes())
+
+			return fmt.Sprintf("hmac:%x", mac.Sum(nil)[:8])
+		}
+	}
+
+	if rule.Mask == "" {
+		return DefaultRedactMask
+	}
+
+	return rule.Mask
+}
+
+// Числовое значение в каноническом виде, false - если значение не является числом.
+func formatNumber(value any) (string, bool) {
+	switch number := value.(type) {
+	case json.Number:
+		return number.String(), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		buffer := &bytes.Buffer{}
+		if err := writeHashValue(buffer, value); err != nil {
+			return "", false
+		}
+
+		return buffer.String(), true
+	}
+
+	return "", false
+}
+
+// Проверка контрольной суммы номера по алгоритму Луна, пробелы и дефисы пропускаются.
+func validLuhn(number string) bool {
+	sum, count := 0, 0
+
+	for index := len(number) - 1; index >= 0; index-- {
+		if number[index] < '0' || number[index] > '9' {
+			continue
+		}
+
+		digit := int(number[index] - '0')
+		if count%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		count++
+	}
+
+	return count > 0 && sum%10 == 0
+}
+
+// Проверка совпадения ключа с шаблоном, где "*" - любая последовательность символов.
+func matchKey(pattern, key string) bool {
+	parts := strings.Split(pattern, Wildcard)
+	if len(parts) == 1 {
+		return pattern == key
+	}
+
+	if !strings.HasPrefix(key, parts[0]) {
+		return false
+	}
+
+	key = key[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		position := strings.Index(key, part)
+		if position < 0 {
+			return false
+		}
+
+		key = key[position+len(part):]
+	}
+
+	return strings.HasSuffix(key, parts[len(parts)-1])
+}
+
+// Преобразование объекта в значение slog.
+func logValue(j *Nested) slog.Value {
+	switch {
+	case j == nil:
+		return slog.AnyValue(nil)
+	case j.IsValue():
+		return slog.AnyValue(j.value)
+	case j.IsArray():
+		return slog.AnyValue(j.ToObject())
+	}
+
+	attrs := make([]slog.Attr, 0, len(j.nested))
+	for _, k := range sortedKeys(j.nested) {
+		attrs = append(attrs, slog.Attr{Key: k, Value: logValue(j.nested[k])})
+	}
+
+	return slog.GroupValue(attrs...)
+}
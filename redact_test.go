@@ -0,0 +1,112 @@
+package nested
+
+import (
+	"bytes"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Redact(t *testing.T) {
+	source := `{
+		"user": {"name": "alice", "Password": "secret", "contact": "mail alice@example.com now"},
+		"auth": {"Access_Token": "abc", "tokens": ["x", "y"], "refresh": {"token": "def"}},
+		"payment": {"card": "4111 1111 1111 1111", "number": 4111111111111111, "order": "order 1697040000123 ts", "amount": 10},
+		"items": [{"secret": 1}, {"secret": 2}],
+		"note": null
+	}`
+	nested := FromJSONString(source)
+
+	result := nested.Redact(
+		RedactRule{Keys: []string{"*token*", "password"}},
+		RedactRule{Paths: []string{"items.*.secret", "note"}, Mask: "[hidden]"},
+		RedactRule{Values: []*regexp.Regexp{RedactEmailPattern, RedactCardNumberPattern}},
+	)
+
+	assert.Equal(t, `{`+
+		`"auth":{"Access_Token":"***","refresh":{"token":"***"},"tokens":"***"},`+
+		`"items":[{"secret":"[hidden]"},{"secret":"[hidden]"}],`+
+		`"note":"[hidden]",`+
+		`"payment":{"amount":10,"card":"***","number":"***","order":"order 1697040000123 ts"},`+
+		`"user":{"Password":"***","contact":"mail *** now","name":"alice"}`+
+		`}`, result.ToJSONString())
+
+	// исходный объект не изменяется
+	assert.Equal(t, FromJSONString(source).ToJSONString(), nested.ToJSONString())
+
+	// одинаковые значения с одним ключом дают одинаковые хеши
+	key := []byte("secret key")
+	source = `{"a": {"email": "x@example.com"}, "b": "to x@example.com", "c": {"email": [1]}}`
+	hashed := FromJSONString(source).Redact(
+		RedactRule{Keys: []string{"email"}, HashKey: key},
+		RedactRule{Values: []*regexp.Regexp{RedactEmailPattern}, HashKey: key},
+	)
+
+	a, _ := hashed.GetValue("a", "email")
+	b, _ := hashed.GetValue("b")
+	c, _ := hashed.GetValue("c", "email")
+
+	assert.Regexp(t, `^hmac:[0-9a-f]{16}$`, a)
+	assert.Equal(t, "to "+a.(string), b)
+	assert.NotEqual(t, a, c)
+
+	other, _ := FromJSONString(source).Redact(RedactRule{Keys: []string{"email"}, HashKey: []byte("other key")}).GetValue("a", "email")
+	assert.NotEqual(t, a, other)
+
+	// значения, которые нельзя записать в каноническом виде, заменяются маской
+	invalid := &Nested{isValue: true, value: math.NaN()}
+	assert.Equal(t, `***`, invalid.Redact(RedactRule{Paths: []string{""}, HashKey: key}).ToJSONString())
+
+	assert.Nil(t, (*Nested)(nil).Redact())
+}
+
+func Test_validLuhn(t *testing.T) {
+	assert.True(t, validLuhn("4111 1111 1111 1111"))
+	assert.True(t, validLuhn("5500-0000-0000-0004"))
+	assert.False(t, validLuhn("4111 1111 1111 1112"))
+	assert.False(t, validLuhn("1697040000123"))
+	assert.False(t, validLuhn(""))
+}
+
+func Test_matchKey(t *testing.T) {
+	assert.True(t, matchKey("password", "password"))
+	assert.False(t, matchKey("password", "passwords"))
+	assert.True(t, matchKey("*token*", "token"))
+	assert.True(t, matchKey("*token*", "access_token_id"))
+	assert.True(t, matchKey("api*key", "api_key"))
+	assert.True(t, matchKey("a*b*a", "aba"))
+	assert.False(t, matchKey("a*b*a", "ab"))
+	assert.False(t, matchKey("*token", "tokens"))
+	assert.True(t, matchKey("*", ""))
+}
+
+func Test_Redacted(t *testing.T) {
+	nested := FromJSONString(`{"user": {"name": "alice", "password": "secret"}, "tags": ["a", "b"], "id": 1}`)
+
+	buffer := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+
+			return a
+		},
+	}))
+
+	logger.Info("request", "body", nested.Redacted(RedactRule{Keys: []string{"password"}}))
+
+	assert.Equal(t,
+		`{"msg":"request","body":{"id":1,"tags":["a","b"],"user":{"name":"alice","password":"***"}}}`,
+		strings.TrimSpace(buffer.String()))
+
+	// скрытие выполняется при записи в лог
+	assert.Nil(t, nested.SetValue("bob", "user", "name"))
+	assert.Equal(t,
+		slog.GroupValue(slog.String("name", "bob"), slog.String("password", "***")).String(),
+		nested.Redacted(RedactRule{Keys: []string{"password"}}).LogValue().Group()[2].Value.String())
+}
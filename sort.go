@@ -0,0 +1,365 @@
+package nested
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Порядок видов объектов при сравнении.
+const (
+	rankMissing = iota
+	rankNull
+	rankBool
+	rankNumber
+	rankString
+	rankOther
+	rankArray
+	rankNested
+)
+
+// Сравнение объектов.
+//
+// Возвращает -1, если a меньше b, 0, если они равны, и 1, если a больше b.
+// Объекты разных видов упорядочены так: nil, null, логические значения, числа, строки,
+// прочие скалярные значения, массивы, объекты ключ-значение.
+//
+// Числа всех целых и дробных типов сравниваются по значению без округления, NaN меньше остальных чисел.
+// Строки сравниваются побайтово, false меньше true. Прочие скалярные значения сравниваются
+// по строковому представлению fmt.Sprint. Массивы сравниваются поэлементно, затем по длине.
+// Объекты ключ-значение сравниваются по отсортированным спискам ключей, затем по значениям в порядке ключей.
+func Compare(a, b *Nested) int {
+	rankA, rankB := compareRank(a), compareRank(b)
+	if rankA != rankB {
+		return cmp.Compare(rankA, rankB)
+	}
+
+	switch rankA {
+	case rankBool:
+		boolA, boolB := a.value.(bool), b.value.(bool)
+		if boolA == boolB {
+			return 0
+		}
+
+		if !boolA {
+			return -1
+		}
+
+		return 1
+	case rankNumber:
+		return compareNumbers(a.value, b.value)
+	case rankString:
+		return strings.Compare(a.value.(string), b.value.(string))
+	case rankOther:
+		return strings.Compare(fmt.Sprint(a.value), fmt.Sprint(b.value))
+	case rankArray:
+		for index := 0; index < min(len(a.array), len(b.array)); index++ {
+			if result := Compare(a.array[index], b.array[index]); result != 0 {
+				return result
+			}
+		}
+
+		return cmp.Compare(len(a.array), len(b.array))
+	case rankNested:
+		keysA, keysB := sortedKeys(a.nested), sortedKeys(b.nested)
+		if result := slices.Compare(keysA, keysB); result != 0 {
+			return result
+		}
+
+		for _, k := range keysA {
+			if result := Compare(a.nested[k], b.nested[k]); result != 0 {
+				return result
+			}
+		}
+	}
+
+	return 0
+}
+
+// Сортировка массива по цепочке ключей функцией сравнения.
+//
+// less сообщает, должен ли элемент a стоять перед b. Сортировка устойчивая: равные элементы
+// сохраняют взаимный порядок. Подписчики получают событие замены содержимого массива.
+func (j *Nested) ArraySort(less func(a, b *Nested) bool, keys ...string) error {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return err
+	}
+
+	sorted := slices.Clone(nested.array)
+	slices.SortStableFunc(sorted, func(a, b *Nested) int {
+		if less(a, b) {
+			return -1
+		}
+
+		if less(b, a) {
+			return 1
+		}
+
+		return 0
+	})
+
+	nested.replace(&Nested{isArray: true, array: sorted})
+
+	return nil
+}
+
+// Сортировка массива по цепочке ключей по значениям элементов по цепочке by.
+//
+// Значения сравниваются функцией [Compare]. Элементы без значения по цепочке by (в том числе
+// не являющиеся объектами ключ-значение) считаются меньше остальных. Пустая цепочка by сортирует
+// элементы по их собственным значениям. Сортировка устойчивая.
+//
+// Пример:
+//
+//	nested := FromJSONString(`[{"age": 30}, {"age": 25}, {"name": "x"}]`)
+//	nested.ArraySortBy([]string{"age"}) // [{"name": "x"}, {"age": 25}, {"age": 30}]
+func (j *Nested) ArraySortBy(by []string, keys ...string) error {
+	return j.ArraySort(func(a, b *Nested) bool {
+		return Compare(elementKey(a, by), elementKey(b, by)) < 0
+	}, keys...)
+}
+
+// Удаление из массива по цепочке ключей элементов с повторяющимися значениями по цепочке by.
+//
+// Сохраняется первый элемент для каждого значения, порядок элементов не меняется. Значения
+// сравниваются функцией [Compare], элементы без значения по цепочке by считаются равными друг другу.
+// Пустая цепочка by сравнивает элементы целиком. Удаленные элементы не очищаются.
+// Подписчики получают событие замены содержимого массива.
+func (j *Nested) ArrayUnique(by []string, keys ...string) error {
+	nested, err := j.getArrayNode(keys...)
+	if err != nil {
+		return err
+	}
+
+	// значения уникальных элементов в порядке сортировки для поиска повторов за O(log n)
+	seen := []*Nested{}
+	unique := []*Nested{}
+
+	for _, element := range nested.array {
+		key := elementKey(element, by)
+
+		index, found := slices.BinarySearchFunc(seen, key, Compare)
+		if found {
+			continue
+		}
+
+		seen = slices.Insert(seen, index, key)
+		unique = append(unique, element)
+	}
+
+	if len(unique) == len(nested.array) {
+		return nil
+	}
+
+	nested.replace(&Nested{isArray: true, array: unique})
+
+	return nil
+}
+
+// Группировка элементов массива по цепочке ключей по значениям по цепочке by.
+//
+// Возвращает новый объект ключ-значение, где ключ - значение по цепочке by, а значение - массив
+// копий элементов с этим значением в исходном порядке. Ключи - значения в виде канонического JSON
+// (см. [Nested.ToCanonicalJSON]), поэтому строки записываются в кавычках и не совпадают с числами
+// и логическими значениями: "1" и 1 попадают в разные группы.
+// Элементы без значения по цепочке by пропускаются. Исходный массив не меняется.
+//
+// Пример:
+//
+//	nested := FromJSONString(`[{"role": "admin", "id": 1}, {"role": "dev", "id": 2}, {"role": "admin", "id": 3}]`)
+//	nested.ArrayGroupBy([]string{"role"})
+//	// {"\"admin\"": [{"role": "admin", "id": 1}, {"role": "admin", "id": 3}], "\"dev\"": [{"role": "dev", "id": 2}]}
+func (j *Nested) ArrayGroupBy(by []string, keys ...string) (*Nested, error) {
+	array, err := j.GetArray(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Nested{nested: map[string]*Nested{}}
+
+	for index, element := range array {
+		key := elementKey(element, by)
+		if key == nil {
+			continue
+		}
+
+		group, err := groupName(key)
+		if err != nil {
+			return nil, pathError(append(slices.Clone(keys), strconv.Itoa(index)), "%w", err)
+		}
+
+		if _, ok := result.nested[group]; !ok {
+			result.nested[group] = &Nested{isArray: true, array: []*Nested{}}
+		}
+
+		result.nested[group].array = append(result.nested[group].array, element.Clone())
+	}
+
+	return result, nil
+}
+
+// Получение значения элемента по цепочке ключей by или nil, если его нет.
+func elementKey(element *Nested, by []string) *Nested {
+	if element == nil || len(by) == 0 {
+		return element
+	}
+
+	value, err := element.Get(by...)
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
+
+// Имя группы для значения в [Nested.ArrayGroupBy].
+func groupName(key *Nested) (string, error) {
+	var buffer bytes.Buffer
+	if err := writeCanonicalNested(&buffer, key); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// Вид объекта для сравнения.
+func compareRank(j *Nested) int {
+	switch {
+	case j == nil:
+		return rankMissing
+	case j.isArray:
+		return rankArray
+	case !j.isValue:
+		return rankNested
+	}
+
+	switch j.value.(type) {
+	case nil:
+		return rankNull
+	case bool:
+		return rankBool
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return rankNumber
+	case string:
+		return rankString
+	}
+
+	return rankOther
+}
+
+// Сравнение чисел разных типов по значению.
+func compareNumbers(a, b any) int {
+	intA, okIntA := toInt64(a)
+	intB, okIntB := toInt64(b)
+
+	if okIntA && okIntB {
+		return cmp.Compare(intA, intB)
+	}
+
+	uintA, okUintA := toUint64(a)
+	uintB, okUintB := toUint64(b)
+
+	if okUintA && okUintB {
+		return cmp.Compare(uintA, uintB)
+	}
+
+	// беззнаковое, не помещающееся в int64, больше MaxInt64 и поэтому больше любого int64
+	if okIntA && okUintB {
+		return -1
+	}
+
+	if okUintA && okIntB {
+		return 1
+	}
+
+	// cmp.Compare считает NaN меньше остальных чисел
+	floatA, floatB := toFloat64(a), toFloat64(b)
+	if math.IsNaN(floatA) || math.IsNaN(floatB) || (!okIntA && !okUintA && !okIntB && !okUintB) {
+		return cmp.Compare(floatA, floatB)
+	}
+
+	// целые числа больше 2^53 не всегда точно представимы в float64, поэтому целое и дробное
+	// число сравниваются без округления
+	return toBigFloat(a).Cmp(toBigFloat(b))
+}
+
+// Приведение числа к big.Float без потери точности.
+func toBigFloat(value any) *big.Float {
+	if i, ok := toInt64(value); ok {
+		return new(big.Float).SetInt64(i)
+	}
+
+	if u, ok := toUint64(value); ok {
+		return new(big.Float).SetUint64(u)
+	}
+
+	return big.NewFloat(toFloat64(value))
+}
+
+// Приведение знакового целого числа к int64.
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return int64(v), true
+		}
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), true
+		}
+	}
+
+	return 0, false
+}
+
+// Приведение беззнакового целого числа к uint64.
+func toUint64(value any) (uint64, bool) {
+	switch v := value.(type) {
+	case uint:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	}
+
+	return 0, false
+}
+
+// Приведение числа к float64.
+func toFloat64(value any) float64 {
+	switch v := value.(type) {
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	}
+
+	if i, ok := toInt64(value); ok {
+		return float64(i)
+	}
+
+	u, _ := toUint64(value)
+
+	return float64(u)
+}
@@ -0,0 +1,198 @@
+package nested
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Compare(t *testing.T) {
+	// значения в порядке возрастания
+	ordered := []*Nested{
+		nil,
+		{isValue: true, value: nil},
+		{isValue: true, value: false},
+		{isValue: true, value: true},
+		{isValue: true, value: math.NaN()},
+		{isValue: true, value: math.Inf(-1)},
+		{isValue: true, value: int64(math.MinInt64)},
+		{isValue: true, value: -1.5},
+		{isValue: true, value: int8(-1)},
+		{isValue: true, value: 0},
+		{isValue: true, value: float32(0.5)},
+		{isValue: true, value: uint(1)},
+		{isValue: true, value: 1.5},
+		{isValue: true, value: float64(1 << 53)},
+		{isValue: true, value: 1<<53 + 1},
+		{isValue: true, value: float64(1<<53 + 2)},
+		{isValue: true, value: int64(math.MaxInt64)},
+		{isValue: true, value: float64(1 << 63)},
+		{isValue: true, value: uint64(math.MaxUint64)},
+		{isValue: true, value: math.Inf(1)},
+		{isValue: true, value: ""},
+		{isValue: true, value: "a"},
+		{isValue: true, value: "b"},
+		{isValue: true, value: []byte("x")},
+		FromJSONString(`[]`),
+		FromJSONString(`[1]`),
+		FromJSONString(`[1, 2]`),
+		FromJSONString(`[2]`),
+		{},
+		FromJSONString(`{"a": 1}`),
+		FromJSONString(`{"a": 2}`),
+		FromJSONString(`{"a": 1, "b": 1}`),
+		FromJSONString(`{"b": 0}`),
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+
+			assert.Equal(t, expected, Compare(ordered[i], ordered[j]), "%d %d", i, j)
+		}
+	}
+
+	assert.Equal(t, 0, Compare(&Nested{isValue: true, value: 1}, &Nested{isValue: true, value: 1.0}))
+	assert.Equal(t, 0, Compare(&Nested{isValue: true, value: uint8(1)}, &Nested{isValue: true, value: int64(1)}))
+	assert.Equal(t, 0, Compare(&Nested{isValue: true, value: uint64(1 << 63)}, &Nested{isValue: true, value: float64(1 << 63)}))
+}
+
+func Test_ArraySort(t *testing.T) {
+	nested := FromJSONString(`{"users": [
+		{"name": "c", "age": 30},
+		{"name": "a", "age": 25},
+		{"name": "b"},
+		{"name": "d", "age": 25},
+		{"name": "e", "age": "unknown"}
+	]}`)
+
+	names := func() []any {
+		array, _ := nested.GetArray("users")
+
+		result := []any{}
+		for _, element := range array {
+			name, _ := element.GetValue("name")
+			result = append(result, name)
+		}
+
+		return result
+	}
+
+	// устойчивая сортировка со значениями разных видов
+	assert.Nil(t, nested.ArraySortBy([]string{"age"}, "users"))
+	assert.Equal(t, []any{"b", "a", "d", "c", "e"}, names())
+
+	assert.Nil(t, nested.ArraySort(func(a, b *Nested) bool {
+		nameA, _ := a.GetValue("name")
+		nameB, _ := b.GetValue("name")
+		return nameA.(string) > nameB.(string)
+	}, "users"))
+	assert.Equal(t, []any{"e", "d", "c", "b", "a"}, names())
+
+	array := FromJSONString(`[3, "a", 1.5, true, null, [1], {"a": 1}, 2]`)
+	assert.Nil(t, array.ArraySortBy(nil))
+	assert.Equal(t, `[null,true,1.5,2,3,"a",[1],{"a":1}]`, array.ToJSONString())
+
+	assert.EqualError(t, nested.ArraySortBy(nil, "missing"), "key 'missing' not found")
+	assert.EqualError(t, FromJSONString(`1`).ArraySortBy(nil), "is value")
+
+	// сортировка отслеживается как замена содержимого массива
+	events := []ChangeEvent{}
+	array.Subscribe(func(event ChangeEvent) {
+		events = append(events, event)
+	})
+
+	assert.Nil(t, array.ArraySort(func(a, b *Nested) bool { return Compare(a, b) > 0 }))
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, ChangeSet, events[0].Op)
+		assert.Equal(t, `[null,true,1.5,2,3,"a",[1],{"a":1}]`, events[0].Old.ToJSONString())
+	}
+
+	hash, err := array.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, mustHash(t, FromJSONString(`[{"a":1},[1],"a",3,2,1.5,true,null]`)), hash)
+}
+
+func Test_ArrayUnique(t *testing.T) {
+	nested := FromJSONString(`{"items": [
+		{"id": 1, "v": "a"},
+		{"id": 2, "v": "b"},
+		{"id": 1, "v": "c"},
+		{"v": "d"},
+		{"id": 1.0, "v": "e"},
+		{"v": "f"},
+		{"id": "1", "v": "g"}
+	]}`)
+
+	items, _ := nested.GetArray("items")
+	removed := items[2]
+
+	assert.Nil(t, nested.ArrayUnique([]string{"id"}, "items"))
+
+	values := []any{}
+	array, _ := nested.GetArray("items")
+	for _, element := range array {
+		value, _ := element.GetValue("v")
+		values = append(values, value)
+	}
+
+	assert.Equal(t, []any{"a", "b", "d", "g"}, values)
+
+	// удаленные элементы не очищаются
+	assert.Equal(t, `{"id":1,"v":"c"}`, removed.ToJSONString())
+
+	scalars := FromJSONString(`[1, 2, 1, [1], [1], {"a": 1}, {"a": 1}, "1"]`)
+	assert.Nil(t, scalars.ArrayUnique(nil))
+	assert.Equal(t, `[1,2,[1],{"a":1},"1"]`, scalars.ToJSONString())
+
+	assert.EqualError(t, nested.ArrayUnique(nil), "is nested")
+}
+
+func Test_ArrayGroupBy(t *testing.T) {
+	nested := FromJSONString(`[
+		{"role": "admin", "id": 1},
+		{"role": "dev", "id": 2},
+		{"id": 3},
+		{"role": "admin", "id": 4},
+		{"role": 5, "id": 5},
+		{"role": {"x": [true]}, "id": 6},
+		{"role": "5", "id": 7},
+		{"role": true, "id": 8},
+		{"role": "true", "id": 9}
+	]`)
+
+	groups, err := nested.ArrayGroupBy([]string{"role"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `{`+
+			`"\"5\"":[{"id":7,"role":"5"}],`+
+			`"\"admin\"":[{"id":1,"role":"admin"},{"id":4,"role":"admin"}],`+
+			`"\"dev\"":[{"id":2,"role":"dev"}],`+
+			`"\"true\"":[{"id":9,"role":"true"}],`+
+			`"5":[{"id":5,"role":5}],`+
+			`"true":[{"id":8,"role":true}],`+
+			`"{\"x\":[true]}":[{"id":6,"role":{"x":[true]}}]`+
+			`}`, groups.ToJSONString())
+	}
+
+	// группы содержат копии элементов
+	admins, _ := groups.GetArray(`"admin"`)
+	assert.Nil(t, admins[0].SetValue("root", "role"))
+
+	value, _ := nested.array[0].GetValue("role")
+	assert.Equal(t, "admin", value)
+
+	_, err = FromJSONString(`{"a": 1}`).ArrayGroupBy(nil, "a")
+	assert.EqualError(t, err, "a: is value")
+
+	invalid := FromJSONString(`[1]`)
+	assert.Nil(t, invalid.ArrayAddValue(math.Inf(1)))
+
+	_, err = invalid.ArrayGroupBy(nil)
+	assert.EqualError(t, err, "1: unsupported number +Inf")
+}
@@ -0,0 +1,277 @@
+package nested
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Ошибка, которую может вернуть обработчик [StreamHandler] для штатной остановки разбора.
+// [StreamParser.Parse] в этом случае завершится без ошибки.
+var ErrStreamStop = errors.New("stream stopped")
+
+// Обработчик поддерева, найденного [StreamParser].
+//
+// Получает фактический путь до поддерева (ключи словарей и индексы массивов в виде строк)
+// и само поддерево. После вызова обработчика парсер поддерево не использует.
+// При вложенных шаблонах внутреннее поддерево является частью внешнего.
+//
+// Если обработчик вернет ошибку, разбор будет остановлен с этой ошибкой.
+type StreamHandler func(path []string, nested *Nested) error
+
+// Шаблон пути с обработчиком.
+type streamPattern struct {
+	pattern pathPattern
+	handler StreamHandler
+}
+
+// Потоковый (событийный) парсер JSON для документов, которые не помещаются в память целиком.
+//
+// Для путей регистрируются обработчики с помощью [StreamParser.Handle] или [StreamParser.HandlePointer].
+// Во время разбора каждое поддерево, путь до которого совпадает с одним из шаблонов, собирается в Nested
+// и сразу передается обработчику. Остальные части документа пропускаются без сохранения в памяти.
+//
+// В шаблонах можно использовать [Wildcard] вместо ключа словаря или индекса массива.
+// Значения конвертируются по тем же правилам, что и в [FromJSONString].
+//
+// Если один шаблон вложен в другой, сначала вызываются обработчики вложенных поддеревьев,
+// затем - обработчик внешнего.
+//
+// Поток может содержать несколько JSON-документов подряд (например, в формате JSON Lines),
+// для каждого из них шаблоны применяются от корня.
+//
+// Пример обработки записей большого массива по одной:
+//
+//	parser := NewStreamParser(file)
+//	parser.Handle(
+//		func(path []string, record *Nested) error {
+//			record.GetValue("id")
+//			return nil
+//		},
+//		"records", Wildcard,
+//	)
+//
+//	err := parser.Parse()
+type StreamParser struct {
+	decoder  *json.Decoder
+	patterns []streamPattern
+}
+
+// Создание потокового парсера, читающего JSON из r.
+func NewStreamParser(r io.Reader) *StreamParser {
+	return &StreamParser{decoder: json.NewDecoder(r)}
+}
+
+// Регистрация обработчика для поддеревьев по цепочке ключей.
+//
+// Для элементов массивов в цепочке указывается индекс в виде строки или [Wildcard].
+// Если цепочка ключей пустая, обработчик получит каждый документ потока целиком.
+func (s *StreamParser) Handle(handler StreamHandler, keys ...string) {
+	s.patterns = append(s.patterns, streamPattern{
+		pattern: keysPattern(keys),
+		handler: handler,
+	})
+}
+
+// Регистрация обработчика для поддеревьев по JSON Pointer (RFC 6901).
+//
+// Сегмент "*" интерпретируется как [Wildcard].
+// Пустая строка соответствует корню документа.
+//
+// Пример:
+//
+//	parser.HandlePointer("/records/*/user", handler)
+func (s *StreamParser) HandlePointer(pointer string, handler StreamHandler) error {
+	keys, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+
+	s.Handle(handler, keys...)
+
+	return nil
+}
+
+// Разбор потока до конца с вызовом обработчиков.
+//
+// Возвращает ошибку синтаксиса JSON, ошибку чтения или ошибку обработчика.
+// Если обработчик вернул [ErrStreamStop], разбор завершается без ошибки.
+func (s *StreamParser) Parse() error {
+	for s.decoder.More() {
+		err := s.walk([]string{})
+		if errors.Is(err, ErrStreamStop) {
+			return nil
+		}
+
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// проверка, что после последнего документа нет лишних символов
+	if _, err := s.decoder.Token(); err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// Рекурсивный обход очередного значения в потоке.
+func (s *StreamParser) walk(path []string) error {
+	matched, nested := s.classify(path)
+
+	if len(matched) > 0 {
+		var obj any
+		if err := s.decoder.Decode(&obj); err != nil {
+			return err
+		}
+
+		return s.dispatch(path, FromObject(obj), matched, nested)
+	}
+
+	if !nested {
+		return s.skip()
+	}
+
+	token, err := s.decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		for s.decoder.More() {
+			key, err := s.decoder.Token()
+			if err != nil {
+				return err
+			}
+
+			if err := s.walk(append(path, key.(string))); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for index := 0; s.decoder.More(); index++ {
+			if err := s.walk(append(path, strconv.Itoa(index))); err != nil {
+				return err
+			}
+		}
+	default:
+		// скалярное значение, вложенные шаблоны не могут совпасть
+		return nil
+	}
+
+	// закрывающая скобка
+	_, err = s.decoder.Token()
+
+	return err
+}
+
+// Пропуск очередного значения в потоке без сохранения.
+func (s *StreamParser) skip() error {
+	depth := 0
+
+	for {
+		token, err := s.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// Поиск шаблонов, совпадающих с путем.
+//
+// Возвращает шаблоны, совпадающие с путем полностью, и признак,
+// что есть шаблоны, для которых путь является началом.
+func (s *StreamParser) classify(path []string) ([]streamPattern, bool) {
+	var matched []streamPattern
+	nested := false
+
+	for _, pattern := range s.patterns {
+		if !pattern.pattern.matchPrefix(path) {
+			continue
+		}
+
+		if len(pattern.pattern) == len(path) {
+			matched = append(matched, pattern)
+		} else {
+			nested = true
+		}
+	}
+
+	return matched, nested
+}
+
+// Вызов обработчиков для собранного поддерева и вложенных в него совпадений.
+func (s *StreamParser) dispatch(path []string, nested *Nested, matched []streamPattern, hasNested bool) error {
+	if hasNested {
+		if nested.IsArray() {
+			for index, element := range nested.array {
+				if err := s.dispatchNested(append(path, strconv.Itoa(index)), element); err != nil {
+					return err
+				}
+			}
+		} else if nested.IsNested() {
+			for _, k := range sortedKeys(nested.nested) {
+				if err := s.dispatchNested(append(path, k), nested.nested[k]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, pattern := range matched {
+		if err := pattern.handler(slices.Clone(path), nested); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Поиск совпадений внутри уже собранного поддерева.
+func (s *StreamParser) dispatchNested(path []string, nested *Nested) error {
+	matched, hasNested := s.classify(path)
+	if len(matched) == 0 && !hasNested {
+		return nil
+	}
+
+	return s.dispatch(path, nested, matched, hasNested)
+}
+
+// Разбор JSON Pointer (RFC 6901) в цепочку ключей.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("pointer '%s' must start with '/'", pointer)
+	}
+
+	keys := strings.Split(pointer[1:], "/")
+	for index, key := range keys {
+		keys[index] = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+	}
+
+	return keys, nil
+}
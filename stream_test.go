@@ -0,0 +1,115 @@
+package nested
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StreamParser(t *testing.T) {
+	input := `{
+		"meta": {"count": 3, "skipped": [1, [2, {"a": 3}]]},
+		"records": [
+			{"id": 1, "user": {"name": "a"}},
+			{"id": 2, "user": {"name": "b"}},
+			{"id": 3.5, "user": null}
+		]
+	}`
+
+	type call struct {
+		path  []string
+		value string
+	}
+
+	calls := []call{}
+	handler := func(path []string, nested *Nested) error {
+		calls = append(calls, call{path, nested.ToJSONString()})
+		return nil
+	}
+
+	parser := NewStreamParser(strings.NewReader(input))
+	parser.Handle(handler, "records", Wildcard)
+	parser.Handle(handler, "meta", "count")
+
+	assert.Nil(t, parser.Parse())
+	assert.Equal(t, []call{
+		{[]string{"meta", "count"}, "3"},
+		{[]string{"records", "0"}, `{"id":1,"user":{"name":"a"}}`},
+		{[]string{"records", "1"}, `{"id":2,"user":{"name":"b"}}`},
+		{[]string{"records", "2"}, `{"id":3.5,"user":null}`},
+	}, calls)
+
+	calls = []call{}
+	parser = NewStreamParser(strings.NewReader(input))
+	assert.Nil(t, parser.HandlePointer("/records/1/user/name", handler))
+	assert.Nil(t, parser.HandlePointer("/records/*/user", handler))
+	assert.Nil(t, parser.HandlePointer("/records/1", handler))
+
+	assert.Nil(t, parser.Parse())
+	assert.Equal(t, []call{
+		{[]string{"records", "0", "user"}, `{"name":"a"}`},
+		{[]string{"records", "1", "user", "name"}, "b"},
+		{[]string{"records", "1", "user"}, `{"name":"b"}`},
+		{[]string{"records", "1"}, `{"id":2,"user":{"name":"b"}}`},
+		{[]string{"records", "2", "user"}, "null"},
+	}, calls)
+
+	calls = []call{}
+	parser = NewStreamParser(strings.NewReader("{\"a\": 1}\n{\"a\": 2}\n[3]"))
+	assert.Nil(t, parser.HandlePointer("", handler))
+	parser.Handle(handler, "a")
+
+	assert.Nil(t, parser.Parse())
+	assert.Equal(t, []call{
+		{[]string{"a"}, "1"},
+		{[]string{}, `{"a":1}`},
+		{[]string{"a"}, "2"},
+		{[]string{}, `{"a":2}`},
+		{[]string{}, `[3]`},
+	}, calls)
+
+	calls = []call{}
+	parser = NewStreamParser(strings.NewReader(`{"a~/b": {"c": 1}}`))
+	assert.Nil(t, parser.HandlePointer("/a~0~1b/c", handler))
+
+	assert.Nil(t, parser.Parse())
+	assert.Equal(t, []call{{[]string{"a~/b", "c"}, "1"}}, calls)
+
+	parser = NewStreamParser(strings.NewReader(input))
+	assert.EqualError(t, parser.HandlePointer("records", handler), "pointer 'records' must start with '/'")
+
+	count := 0
+	parser = NewStreamParser(strings.NewReader(input))
+	parser.Handle(func(path []string, nested *Nested) error {
+		count++
+		return ErrStreamStop
+	}, "records", Wildcard)
+
+	assert.Nil(t, parser.Parse())
+	assert.Equal(t, 1, count)
+
+	parser = NewStreamParser(strings.NewReader(input))
+	parser.Handle(func(path []string, nested *Nested) error {
+		return errors.New("handler failed")
+	}, "records", "2")
+
+	assert.EqualError(t, parser.Parse(), "handler failed")
+
+	parser = NewStreamParser(strings.NewReader(`{"records": [{"id": 1}, {"id": `))
+	parser.Handle(handler, "records", Wildcard)
+
+	assert.Equal(t, io.ErrUnexpectedEOF, parser.Parse())
+
+	parser = NewStreamParser(strings.NewReader(`{"records": [1, 2}`))
+	parser.Handle(handler, "records", Wildcard)
+
+	assert.EqualError(t, parser.Parse(), "invalid character '}' after array element")
+
+	parser = NewStreamParser(strings.NewReader(`{"skip": [1, 2]}]`))
+	parser.Handle(handler, "records")
+
+	assert.EqualError(t, parser.Parse(), "invalid character ']' looking for beginning of value")
+}
@@ -0,0 +1,161 @@
+package nested
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Создание объекта из TOML-строки.
+//
+// Таблицы и встроенные таблицы (inline tables) становятся объектами ключ-значение,
+// массивы и массивы таблиц - массивами.
+// Целые числа сохраняются как int, дробные - как float64, дата и время всех четырех видов TOML - как time.Time.
+// Для локальных даты и времени без часового пояса используются специальные локации
+// "datetime-local", "date-local" и "time-local", благодаря чему при обратной конвертации
+// через [Nested.ToTOML] вид значения сохраняется.
+//
+// Ошибки содержат номер строки в документе.
+//
+// Пример:
+//
+//	nested, err := FromTOML(`
+//	[server]
+//	port = 8080
+//
+//	[[server.hosts]]
+//	name = "a"
+//	`)
+//
+//	nested.GetValue("server", "port") // 8080, nil
+func FromTOML(data string) (*Nested, error) {
+	var obj map[string]any
+	if _, err := toml.Decode(data, &obj); err != nil {
+		return nil, err
+	}
+
+	return fromTOMLObject(obj), nil
+}
+
+// Рекурсивная конвертация результата разбора TOML в Nested.
+func fromTOMLObject(obj any) *Nested {
+	switch value := obj.(type) {
+	case map[string]any:
+		nested := make(map[string]*Nested, len(value))
+
+		for k := range value {
+			nested[k] = fromTOMLObject(value[k])
+		}
+
+		return &Nested{nested: nested}
+	case []map[string]any:
+		array := make([]*Nested, 0, len(value))
+
+		for _, element := range value {
+			array = append(array, fromTOMLObject(element))
+		}
+
+		return &Nested{isArray: true, array: array}
+	case []any:
+		array := make([]*Nested, 0, len(value))
+
+		for _, element := range value {
+			array = append(array, fromTOMLObject(element))
+		}
+
+		return &Nested{isArray: true, array: array}
+	case int64:
+		return &Nested{isValue: true, value: int(value)}
+	}
+
+	return &Nested{isValue: true, value: obj}
+}
+
+// Конвертация объекта в TOML-строку.
+//
+// Исходный объект должен быть вида ключ-значение, иначе вернется ошибка.
+// Ключи сортируются в алфавитном порядке, поэтому результат детерминирован
+// и при повторном разборе через [FromTOML] дает исходный объект.
+//
+// В TOML отсутствует null, поэтому для объектов-значений nil и нулевых указателей вернется ошибка.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"title": "x", "server": {"port": 8080}}`)
+//
+//	nested.ToTOML()
+//
+//	// title = "x"
+//	//
+//	// [server]
+//	// port = 8080
+func (j *Nested) ToTOML() (string, error) {
+	if j.IsValue() {
+		return "", fmt.Errorf("is value")
+	}
+
+	if j.IsArray() {
+		return "", fmt.Errorf("is array")
+	}
+
+	obj, err := toTOMLObject(j, []string{})
+	if err != nil {
+		return "", err
+	}
+
+	buffer := &bytes.Buffer{}
+
+	encoder := toml.NewEncoder(buffer)
+	encoder.Indent = ""
+
+	if err := encoder.Encode(obj); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// Рекурсивная конвертация объекта в интерфейс для кодировщика TOML с проверкой значений nil.
+func toTOMLObject(j *Nested, path []string) (any, error) {
+	if j == nil || (j.IsValue() && j.value == nil) {
+		if len(path) == 0 {
+			return nil, fmt.Errorf("null values are not supported in TOML")
+		}
+
+		return nil, fmt.Errorf("%s: null values are not supported in TOML", strings.Join(path, "."))
+	}
+
+	if j.IsValue() {
+		return j.value, nil
+	}
+
+	if j.IsArray() {
+		result := make([]any, 0, len(j.array))
+
+		for index, element := range j.array {
+			value, err := toTOMLObject(element, append(path, fmt.Sprint(index)))
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, value)
+		}
+
+		return result, nil
+	}
+
+	result := make(map[string]any, len(j.nested))
+
+	for k := range j.nested {
+		value, err := toTOMLObject(j.nested[k], append(path, k))
+		if err != nil {
+			return nil, err
+		}
+
+		result[k] = value
+	}
+
+	return result, nil
+}
@@ -0,0 +1,142 @@
+package nested
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FromTOML(t *testing.T) {
+	nested, err := FromTOML(`
+value = 42
+float = 42.5
+bool = true
+string = "42"
+offset_datetime = 1979-05-27T07:32:00Z
+array = [142, "string in array"]
+inline = {value = 242, nested = {value = "string"}}
+
+[nested]
+value = "string"
+
+[nested.nested]
+value = "string in nested"
+
+[[nested.array]]
+value = 242
+
+[[nested.array]]
+value = 1242
+`)
+	if assert.Nil(t, err) {
+		assert.Equal(t,
+			&Nested{nested: map[string]*Nested{
+				"value":           {isValue: true, value: 42},
+				"float":           {isValue: true, value: 42.5},
+				"bool":            {isValue: true, value: true},
+				"string":          {isValue: true, value: "42"},
+				"offset_datetime": {isValue: true, value: time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)},
+				"array": {isArray: true, array: []*Nested{
+					{isValue: true, value: 142},
+					{isValue: true, value: "string in array"},
+				}},
+				"inline": {nested: map[string]*Nested{
+					"value": {isValue: true, value: 242},
+					"nested": {nested: map[string]*Nested{
+						"value": {isValue: true, value: "string"},
+					}},
+				}},
+				"nested": {nested: map[string]*Nested{
+					"value": {isValue: true, value: "string"},
+					"nested": {nested: map[string]*Nested{
+						"value": {isValue: true, value: "string in nested"},
+					}},
+					"array": {isArray: true, array: []*Nested{
+						{nested: map[string]*Nested{"value": {isValue: true, value: 242}}},
+						{nested: map[string]*Nested{"value": {isValue: true, value: 1242}}},
+					}},
+				}},
+			}},
+			nested,
+		)
+	}
+
+	nested, err = FromTOML(`
+local_datetime = 1979-05-27T07:32:00
+local_date = 1979-05-27
+local_time = 07:32:00
+`)
+	if assert.Nil(t, err) {
+		for _, key := range []string{"local_datetime", "local_date", "local_time"} {
+			value, _ := nested.GetValue(key)
+			assert.IsType(t, time.Time{}, value)
+		}
+
+		value, _ := nested.GetValue("local_date")
+		assert.Equal(t, "date-local", value.(time.Time).Location().String())
+	}
+
+	nested, err = FromTOML("")
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{nested: map[string]*Nested{}}, nested)
+	}
+
+	_, err = FromTOML("a = 1\nb = [\n")
+	assert.EqualError(t, err, `toml: line 2 (last key "b"): unexpected EOF; expected value`)
+
+	_, err = FromTOML("a = 1\na = 2\n")
+	assert.EqualError(t, err, `toml: line 2 (last key "a"): Key 'a' has already been defined.`)
+}
+
+func Test_ToTOML(t *testing.T) {
+	nested := testNested()
+
+	data, err := nested.ToTOML()
+	if assert.Nil(t, err) {
+		assert.Equal(t, `array = [142, "string in array"]
+value = 42
+
+[nested]
+array = [{value = 242}, [{value = "string in nested array"}], {value = 1242}]
+value = "string"
+[nested.nested]
+value = "string in nested"
+`, data)
+
+		parsed, err := FromTOML(data)
+		if assert.Nil(t, err) {
+			assert.True(t, Equals(&nested, parsed))
+		}
+	}
+
+	input := `date = 1979-05-27
+datetime = 1979-05-27T07:32:00
+offset_datetime = 1979-05-27T07:32:00Z
+time = 07:32:00
+
+[[records]]
+id = 1
+
+[[records]]
+id = 2
+tags = ["a", "b"]
+`
+
+	nested2, err := FromTOML(input)
+	if assert.Nil(t, err) {
+		data, err := nested2.ToTOML()
+		if assert.Nil(t, err) {
+			assert.Equal(t, input, data)
+		}
+	}
+
+	_, err = FromJSONString(`[1, 2]`).ToTOML()
+	assert.EqualError(t, err, "is array")
+
+	_, err = FromJSONString(`42`).ToTOML()
+	assert.EqualError(t, err, "is value")
+
+	_, err = FromJSONString(`{"a": {"b": [1, null]}}`).ToTOML()
+	assert.EqualError(t, err, "a.b.1: null values are not supported in TOML")
+}
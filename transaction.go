@@ -0,0 +1,422 @@
+package nested
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+)
+
+// Ошибка операций с уже завершенной транзакцией.
+var ErrTransactionDone = errors.New("transaction has already been committed or rolled back")
+
+// Транзакция изменений объекта.
+//
+// Изменения применяются к объекту сразу, а для каждого успешного изменения запоминается обратная операция.
+// [Transaction.Rollback] выполняет обратные операции в обратном порядке и возвращает объект в состояние
+// на момент вызова [Nested.Begin]. Подписчики (см. [Nested.Subscribe]) получают события как для изменений,
+// так и для их отмены.
+//
+// Отменяются только изменения, выполненные через функции транзакции. Изменения, выполненные
+// напрямую через объект во время транзакции, не отслеживаются и могут помешать корректной отмене.
+//
+// Не является конкурентно-безопасной.
+type Transaction struct {
+	nested  *Nested
+	undo    []func()
+	removed []*Nested // элементы, удаленные ArrayDelete, очищаются только при фиксации
+	done    bool
+}
+
+// Начало транзакции изменений объекта.
+//
+// Пример:
+//
+//	tx := nested.Begin()
+//
+//	if err := tx.SetValue("Bob", "user", "name"); err != nil {
+//		tx.Rollback()
+//		return err
+//	}
+//
+//	if err := tx.Delete("user", "age"); err != nil {
+//		tx.Rollback()
+//		return err
+//	}
+//
+//	tx.Commit()
+func (j *Nested) Begin() *Transaction {
+	return &Transaction{nested: j}
+}
+
+// Выполнение функции в транзакции.
+//
+// Если функция вернет ошибку, все изменения отменяются и возвращается эта ошибка, иначе изменения фиксируются.
+func (j *Nested) Update(f func(tx *Transaction) error) error {
+	tx := j.Begin()
+
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Фиксация изменений.
+//
+// Элементы, удаленные из массивов через [Transaction.ArrayDelete], очищаются функцией Clear.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	t.done = true
+	t.undo = nil
+
+	for _, element := range t.removed {
+		element.Clear()
+	}
+
+	t.removed = nil
+
+	return nil
+}
+
+// Отмена всех изменений, выполненных через транзакцию.
+func (t *Transaction) Rollback() error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	t.done = true
+
+	for index := len(t.undo) - 1; index >= 0; index-- {
+		t.undo[index]()
+	}
+
+	t.undo = nil
+	t.removed = nil
+
+	return nil
+}
+
+// Сохранение объекта по цепочке ключей, см. [Nested.Set].
+func (t *Transaction) Set(nested *Nested, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	undo := t.undoSet(keys)
+
+	if err := t.nested.Set(nested, keys...); err != nil {
+		return err
+	}
+
+	t.undo = append(t.undo, undo)
+
+	return nil
+}
+
+// Сохранение скалярного значения по цепочке ключей, см. [Nested.SetValue].
+func (t *Transaction) SetValue(value any, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	return t.apply(func() error { return t.nested.SetValue(value, keys...) }, keys)
+}
+
+// Сохранение объекта ключ-значение по цепочке ключей, см. [Nested.SetMap].
+func (t *Transaction) SetMap(nested map[string]*Nested, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	return t.apply(func() error { return t.nested.SetMap(nested, keys...) }, keys)
+}
+
+// Сохранение массива по цепочке ключей, см. [Nested.SetArray].
+func (t *Transaction) SetArray(array []*Nested, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	return t.apply(func() error { return t.nested.SetArray(array, keys...) }, keys)
+}
+
+// Удаление вложенного объекта по цепочке ключей, см. [Nested.Delete].
+func (t *Transaction) Delete(keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	previous, err := t.nested.Get(keys...)
+	existed := err == nil
+
+	if err := t.nested.Delete(keys...); err != nil {
+		return err
+	}
+
+	if existed {
+		keys = slices.Clone(keys)
+		t.undo = append(t.undo, func() {
+			t.nested.Set(previous, keys...)
+		})
+	}
+
+	return nil
+}
+
+// Добавление указателя на объект в массив по цепочке ключей, см. [Nested.ArrayAdd].
+func (t *Transaction) ArrayAdd(element *Nested, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	if err := t.nested.ArrayAdd(element, keys...); err != nil {
+		return err
+	}
+
+	nested, _ := t.nested.getArrayNode(keys...)
+	index := len(nested.array) - 1
+
+	keys = slices.Clone(keys)
+	t.undo = append(t.undo, func() {
+		t.nested.ArrayRemoveAt(index, keys...)
+	})
+
+	return nil
+}
+
+// Добавление объекта-значения в массив по цепочке ключей, см. [Nested.ArrayAddValue].
+func (t *Transaction) ArrayAddValue(element any, keys ...string) error {
+	return t.ArrayAdd(&Nested{isValue: true, value: element}, keys...)
+}
+
+// Добавление объекта-массива в массив по цепочке ключей, см. [Nested.ArrayAddArray].
+func (t *Transaction) ArrayAddArray(element []*Nested, keys ...string) error {
+	return t.ArrayAdd(&Nested{isArray: true, array: element}, keys...)
+}
+
+// Удаление элементов массива по цепочке ключей, см. [Nested.ArrayDelete].
+//
+// Удаленные элементы очищаются только при фиксации транзакции, чтобы их можно было вернуть при отмене.
+func (t *Transaction) ArrayDelete(f func(element *Nested) bool, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	nested, err := t.nested.getArrayNode(keys...)
+	if err != nil {
+		return err
+	}
+
+	previous := slices.Clone(nested.array)
+
+	removed, err := t.nested.arrayDelete(f, keys...)
+	if err != nil {
+		return err
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+
+	t.removed = append(t.removed, removed...)
+
+	keys = slices.Clone(keys)
+	t.undo = append(t.undo, func() {
+		nested.array = previous
+
+		for index, element := range previous {
+			if slices.Contains(removed, element) {
+				nested.notify(ChangeAdd, nil, element, strconv.Itoa(index))
+			}
+		}
+	})
+
+	return nil
+}
+
+// Вставка элемента в массив по индексу по цепочке ключей, см. [Nested.ArrayInsert].
+func (t *Transaction) ArrayInsert(index int, element *Nested, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	nested, err := t.nested.getArrayNode(keys...)
+	if err != nil {
+		return err
+	}
+
+	index, err = arrayIndex(index, len(nested.array)+1, keys)
+	if err != nil {
+		return err
+	}
+
+	if err := t.nested.ArrayInsert(index, element, keys...); err != nil {
+		return err
+	}
+
+	keys = slices.Clone(keys)
+	t.undo = append(t.undo, func() {
+		t.nested.ArrayRemoveAt(index, keys...)
+	})
+
+	return nil
+}
+
+// Замена элемента массива по индексу по цепочке ключей, см. [Nested.ArraySet].
+func (t *Transaction) ArraySet(index int, element *Nested, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	previous, err := t.nested.ArrayGet(index, keys...)
+	if err != nil {
+		return err
+	}
+
+	if err := t.nested.ArraySet(index, element, keys...); err != nil {
+		return err
+	}
+
+	keys = slices.Clone(keys)
+	t.undo = append(t.undo, func() {
+		t.nested.ArraySet(index, previous, keys...)
+	})
+
+	return nil
+}
+
+// Удаление элемента массива по индексу по цепочке ключей, см. [Nested.ArrayRemoveAt].
+func (t *Transaction) ArrayRemoveAt(index int, keys ...string) (*Nested, error) {
+	if t.done {
+		return nil, ErrTransactionDone
+	}
+
+	nested, err := t.nested.getArrayNode(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err = arrayIndex(index, len(nested.array), keys)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := t.nested.ArrayRemoveAt(index, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	keys = slices.Clone(keys)
+	t.undo = append(t.undo, func() {
+		t.nested.ArrayInsert(index, removed, keys...)
+	})
+
+	return removed, nil
+}
+
+// Перемещение элемента массива по цепочке ключей, см. [Nested.ArrayMove].
+func (t *Transaction) ArrayMove(from, to int, keys ...string) error {
+	if t.done {
+		return ErrTransactionDone
+	}
+
+	nested, err := t.nested.getArrayNode(keys...)
+	if err != nil {
+		return err
+	}
+
+	if from, err = arrayIndex(from, len(nested.array), keys); err != nil {
+		return err
+	}
+
+	if to, err = arrayIndex(to, len(nested.array), keys); err != nil {
+		return err
+	}
+
+	if err := t.nested.ArrayMove(from, to, keys...); err != nil {
+		return err
+	}
+
+	keys = slices.Clone(keys)
+	t.undo = append(t.undo, func() {
+		t.nested.ArrayMove(to, from, keys...)
+	})
+
+	return nil
+}
+
+// Выполнение функции сохранения с запоминанием обратной операции.
+//
+// Без ключей функции сохранения меняют содержимое исходного объекта на месте,
+// поэтому запоминается его прежнее содержимое.
+func (t *Transaction) apply(set func() error, keys []string) error {
+	if len(keys) > 0 {
+		undo := t.undoSet(keys)
+
+		if err := set(); err != nil {
+			return err
+		}
+
+		t.undo = append(t.undo, undo)
+
+		return nil
+	}
+
+	nested := t.nested
+	previous := &Nested{
+		isValue: nested.isValue,
+		isArray: nested.isArray,
+		nested:  nested.nested,
+		array:   nested.array,
+		value:   nested.value,
+	}
+
+	if err := set(); err != nil {
+		return err
+	}
+
+	t.undo = append(t.undo, func() {
+		nested.replace(previous)
+	})
+
+	return nil
+}
+
+// Обратная операция для сохранения объекта по цепочке ключей.
+//
+// Если в цепочке отсутствует промежуточный ключ, сохранение создаст объекты начиная с него,
+// и для отмены достаточно удалить этот ключ. Иначе восстанавливается прежний объект по последнему ключу
+// или последний ключ удаляется, если его не было.
+func (t *Transaction) undoSet(keys []string) func() {
+	keys = slices.Clone(keys)
+	current := t.nested
+
+	for index, k := range keys {
+		if current == nil || current.IsValue() || current.IsArray() {
+			// сохранение завершится ошибкой, обратная операция не понадобится
+			return nil
+		}
+
+		next, ok := current.nested[k]
+		if !ok {
+			created := keys[:index+1]
+			return func() {
+				t.nested.Delete(created...)
+			}
+		}
+
+		if index == len(keys)-1 {
+			return func() {
+				t.nested.Set(next, keys...)
+			}
+		}
+
+		current = next
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package nested
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TransactionRollback(t *testing.T) {
+	source := `{"user": {"name": "Alice", "roles": ["admin", "dev"]}, "count": 1, "flag": true}`
+	nested := FromJSONString(source)
+
+	hash, err := nested.Hash()
+	assert.Nil(t, err)
+
+	roles, _ := nested.Get("user", "roles")
+	admin, _ := roles.GetArray()
+
+	tx := nested.Begin()
+
+	assert.Nil(t, tx.SetValue("Bob", "user", "name"))
+	assert.Nil(t, tx.SetValue(1, "a", "b", "c"))
+	assert.Nil(t, tx.Set(FromJSONString(`{"x": 1}`), "user", "extra"))
+	assert.Nil(t, tx.SetValue(2, "user", "extra", "y"))
+	assert.Nil(t, tx.Delete("count"))
+	assert.Nil(t, tx.Delete("missing"))
+	assert.Nil(t, tx.ArrayAddValue("ops", "user", "roles"))
+	assert.Nil(t, tx.ArrayDelete(func(element *Nested) bool {
+		value, _ := element.GetValue()
+		return value == "admin" || value == "ops"
+	}, "user", "roles"))
+	assert.Nil(t, tx.SetArray([]*Nested{}, "flag"))
+	assert.EqualError(t, tx.SetValue(1, "flag", "x"), "flag: is array")
+	assert.EqualError(t, tx.Delete("count", "x"), "key 'count' not found")
+
+	assert.Equal(t, `{"a":{"b":{"c":1}},"flag":[],"user":{"extra":{"x":1,"y":2},"name":"Bob","roles":["dev"]}}`, nested.ToJSONString())
+
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, FromJSONString(source).ToJSONString(), nested.ToJSONString())
+
+	// удаленные элементы не очищаются и возвращаются на свои места
+	assert.Same(t, admin[0], roles.array[0])
+	assert.Equal(t, "admin", admin[0].value)
+
+	restored, err := nested.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, hash, restored)
+
+	assert.Equal(t, ErrTransactionDone, tx.Rollback())
+	assert.Equal(t, ErrTransactionDone, tx.Commit())
+	assert.Equal(t, ErrTransactionDone, tx.SetValue(1, "a"))
+}
+
+func Test_TransactionInPlace(t *testing.T) {
+	nested := FromJSONString(`[1, 2]`)
+
+	tx := nested.Begin()
+	assert.Nil(t, tx.ArrayAddValue(3))
+	assert.Nil(t, tx.SetArray([]*Nested{{isValue: true, value: 4}}))
+	assert.Nil(t, tx.ArrayAddValue(5))
+	assert.EqualError(t, tx.SetValue(6), "keys list must contain at least one key")
+	assert.Equal(t, `[4,5]`, nested.ToJSONString())
+
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, FromJSONString(`[1, 2]`), nested)
+
+	value := FromJSONString(`"a"`)
+
+	tx = value.Begin()
+	assert.Nil(t, tx.SetValue("b"))
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, FromJSONString(`"a"`), value)
+
+	empty := &Nested{}
+
+	tx = empty.Begin()
+	assert.Nil(t, tx.SetMap(map[string]*Nested{"a": {isValue: true, value: 1}}))
+	assert.Nil(t, tx.SetValue(2, "b"))
+	assert.Nil(t, tx.Rollback())
+	assert.True(t, empty.IsEmpty())
+}
+
+func Test_TransactionCommit(t *testing.T) {
+	nested := FromJSONString(`{"items": [1, 2, 3]}`)
+
+	items, _ := nested.GetArray("items")
+	removed := items[1]
+
+	assert.Nil(t, nested.Update(func(tx *Transaction) error {
+		if err := tx.SetValue("b", "a"); err != nil {
+			return err
+		}
+
+		return tx.ArrayDelete(func(element *Nested) bool {
+			value, _ := element.GetValue()
+			return value == 2
+		}, "items")
+	}))
+
+	assert.Equal(t, `{"a":"b","items":[1,3]}`, nested.ToJSONString())
+	assert.Equal(t, &Nested{}, removed)
+
+	err := nested.Update(func(tx *Transaction) error {
+		if err := tx.SetValue("c", "a"); err != nil {
+			return err
+		}
+
+		if err := tx.SetValue(1, "a", "b"); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+
+		return nil
+	})
+
+	assert.EqualError(t, err, "update: a: is value")
+	assert.Equal(t, `{"a":"b","items":[1,3]}`, nested.ToJSONString())
+}
+
+func Test_TransactionEvents(t *testing.T) {
+	nested := FromJSONString(`{"a": 1, "list": [1]}`)
+
+	events := []string{}
+	nested.Subscribe(func(event ChangeEvent) {
+		events = append(events, fmt.Sprint(event.Op, event.Path))
+	})
+
+	tx := nested.Begin()
+	assert.Nil(t, tx.SetValue(2, "a"))
+	assert.Nil(t, tx.ArrayAddValue(2, "list"))
+	assert.Nil(t, tx.Rollback())
+
+	assert.Equal(t, []string{
+		"set[a]",
+		"add[list 1]",
+		"remove[list 1]",
+		"set[a]",
+	}, events)
+
+	value, _ := nested.Get("a")
+	assert.Nil(t, value.SetValue(3))
+	assert.Equal(t, "set[a]", events[len(events)-1])
+	assert.Len(t, events, 5)
+}
+
+func Test_TransactionArrayIndex(t *testing.T) {
+	nested := FromJSONString(`{"list": [0, 1, 2, 3]}`)
+
+	list, _ := nested.Get("list")
+	elements, _ := list.GetArray()
+
+	tx := nested.Begin()
+	assert.Nil(t, tx.ArrayInsert(-1, &Nested{isValue: true, value: 4}, "list"))
+	assert.Nil(t, tx.ArraySet(0, &Nested{isValue: true, value: "zero"}, "list"))
+	assert.Nil(t, tx.ArrayMove(-2, 1, "list"))
+
+	removed, err := tx.ArrayRemoveAt(2, "list")
+	if assert.Nil(t, err) {
+		assert.Same(t, elements[1], removed)
+	}
+
+	assert.EqualError(t, tx.ArrayMove(0, 10, "list"), "list: index 10 out of range [-4, 4)")
+	assert.Equal(t, `["zero",3,2,4]`, list.ToJSONString())
+
+	assert.Nil(t, tx.Rollback())
+	assert.Equal(t, `[0,1,2,3]`, list.ToJSONString())
+
+	restored, _ := list.GetArray()
+	assert.Equal(t, elements, restored)
+}
@@ -0,0 +1,164 @@
+package nested
+
+import (
+	"slices"
+	"strconv"
+)
+
+// Новый объект с заменой всех скалярных значений.
+//
+// Функция f вызывается для каждого объекта-значения с путем до него от исходного объекта (для элементов
+// массивов - индекс в виде строки) и возвращает новое значение. Вид объектов и ключи не меняются.
+// Исходный объект не изменяется. Ошибка функции возвращается с путем до значения.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"name": "alice", "tags": ["a", "b"], "age": 30}`)
+//
+//	upper, _ := nested.MapValues(func(path []string, value any) (any, error) {
+//		if s, ok := value.(string); ok {
+//			return strings.ToUpper(s), nil
+//		}
+//
+//		return value, nil
+//	})
+//	// {"age": 30, "name": "ALICE", "tags": ["A", "B"]}
+func (j *Nested) MapValues(f func(path []string, value any) (any, error)) (*Nested, error) {
+	return j.Transform(func(path []string, node *Nested) (*Nested, error) {
+		if !node.isValue {
+			return node, nil
+		}
+
+		value, err := f(path, node.value)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Nested{isValue: true, value: value}, nil
+	})
+}
+
+// Новый объект с заменой вложенных объектов.
+//
+// Объекты обходятся снизу вверх: функция f вызывается для каждого объекта, включая исходный, после
+// обработки всех вложенных в него, и получает копию объекта с уже замененными вложенными объектами.
+// Возвращенный объект используется вместо переданного, nil удаляет объект из родительского
+// (для исходного объекта результатом будет nil). Нулевые указатели во вложенных объектах сохраняются
+// без вызова f.
+//
+// Исходный объект не изменяется. Ошибка функции возвращается с путем до объекта.
+//
+// Пример:
+//
+//	// замена объектов {"$ref": "..."} строками
+//	nested.Transform(func(path []string, node *Nested) (*Nested, error) {
+//		if ref, err := node.GetValue("$ref"); err == nil {
+//			result := &Nested{}
+//			return result, result.SetValue(ref)
+//		}
+//
+//		return node, nil
+//	})
+func (j *Nested) Transform(f func(path []string, node *Nested) (*Nested, error)) (*Nested, error) {
+	if j == nil {
+		return nil, nil
+	}
+
+	return transform(j, []string{}, f)
+}
+
+// Новый объект без вложенных объектов, не удовлетворяющих условию.
+//
+// Функция f вызывается сверху вниз для каждого вложенного объекта (кроме исходного) с путем до него.
+// Если f вернет false, объект удаляется вместе со всеми вложенными, и для них f не вызывается.
+// Из массивов элементы удаляются со сдвигом, поэтому индексы в путях соответствуют исходному объекту.
+// Исходный объект не изменяется.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"a": 1, "b": null, "c": {"d": null, "e": 2}}`)
+//
+//	nested.Filter(func(path []string, node *Nested) bool {
+//		return !node.IsValue() || node.value != nil
+//	})
+//	// {"a": 1, "c": {"e": 2}}
+func (j *Nested) Filter(f func(path []string, node *Nested) bool) *Nested {
+	return filter(j, []string{}, f)
+}
+
+// Свертка элементов массива по цепочке ключей.
+//
+// Функция f вызывается для каждого элемента по порядку с накопленным значением, начиная с initial,
+// и возвращает новое накопленное значение. Ошибка функции прерывает свертку и возвращается с индексом элемента.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"items": [{"price": 10}, {"price": 15}]}`)
+//
+//	total, _ := nested.Reduce(func(acc any, element *Nested) (any, error) {
+//		price, err := element.GetValue("price")
+//		if err != nil {
+//			return nil, err
+//		}
+//
+//		return acc.(int) + price.(int), nil
+//	}, 0, "items")
+//	// 25
+func (j *Nested) Reduce(f func(acc any, element *Nested) (any, error), initial any, keys ...string) (any, error) {
+	array, err := j.GetArray(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	acc := initial
+
+	for index, element := range array {
+		acc, err = f(acc, element)
+		if err != nil {
+			return nil, pathError(append(slices.Clone(keys), strconv.Itoa(index)), "%w", err)
+		}
+	}
+
+	return acc, nil
+}
+
+// Рекурсивная замена объектов снизу вверх.
+func transform(j *Nested, path []string, f func(path []string, node *Nested) (*Nested, error)) (*Nested, error) {
+	node, err := j.copyWith(func(key string, element *Nested) (*Nested, bool, error) {
+		if element == nil {
+			return nil, true, nil
+		}
+
+		result, err := transform(element, append(slices.Clone(path), key), f)
+
+		return result, result != nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := f(path, node)
+	if err != nil {
+		return nil, pathError(path, "%w", err)
+	}
+
+	return result, nil
+}
+
+// Рекурсивное копирование объектов, удовлетворяющих условию.
+func filter(j *Nested, path []string, f func(path []string, node *Nested) bool) *Nested {
+	if j == nil {
+		return nil
+	}
+
+	node, _ := j.copyWith(func(key string, element *Nested) (*Nested, bool, error) {
+		elementPath := append(slices.Clone(path), key)
+		if element != nil && !f(elementPath, element) {
+			return nil, false, nil
+		}
+
+		return filter(element, elementPath, f), true, nil
+	})
+
+	return node
+}
@@ -0,0 +1,181 @@
+package nested
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MapValues(t *testing.T) {
+	source := `{"name": "alice", "tags": ["a", {"b": "c"}], "age": 30, "empty": {}}`
+	nested := FromJSONString(source)
+
+	paths := []string{}
+	result, err := nested.MapValues(func(path []string, value any) (any, error) {
+		paths = append(paths, strings.Join(path, "."))
+
+		if s, ok := value.(string); ok {
+			return strings.ToUpper(s), nil
+		}
+
+		return value, nil
+	})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, `{"age":30,"empty":{},"name":"ALICE","tags":["A",{"b":"C"}]}`, result.ToJSONString())
+		assert.ElementsMatch(t, []string{"age", "name", "tags.0", "tags.1.b"}, paths)
+	}
+
+	// исходный объект не изменяется
+	assert.Equal(t, FromJSONString(source).ToJSONString(), nested.ToJSONString())
+
+	value, err := FromJSONString(`1`).MapValues(func(path []string, value any) (any, error) {
+		return value.(int) + 1, nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `2`, value.ToJSONString())
+	}
+
+	errFailed := errors.New("failed")
+
+	_, err = nested.MapValues(func(path []string, value any) (any, error) {
+		if value == "c" {
+			return nil, errFailed
+		}
+
+		return value, nil
+	})
+	assert.EqualError(t, err, "tags.1.b: failed")
+	assert.True(t, errors.Is(err, errFailed))
+}
+
+func Test_Transform(t *testing.T) {
+	nested := FromJSONString(`{"a": {"$ref": "x"}, "b": [{"$ref": "y"}, {"drop": true}, 1], "c": {"drop": true}}`)
+
+	visited := []string{}
+	result, err := nested.Transform(func(path []string, node *Nested) (*Nested, error) {
+		visited = append(visited, strings.Join(path, "."))
+
+		if ref, err := node.GetValue("$ref"); err == nil {
+			return &Nested{isValue: true, value: ref}, nil
+		}
+
+		if _, err := node.Get("drop"); err == nil {
+			return nil, nil
+		}
+
+		return node, nil
+	})
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, `{"a":"x","b":["y",1]}`, result.ToJSONString())
+	}
+
+	// вложенные объекты обрабатываются раньше родительских
+	assert.Less(t, indexOf(visited, "a.$ref"), indexOf(visited, "a"))
+	assert.Less(t, indexOf(visited, "b.0"), indexOf(visited, "b"))
+	assert.Equal(t, "", visited[len(visited)-1])
+
+	assert.Equal(t, `{"a":{"$ref":"x"},"b":[{"$ref":"y"},{"drop":true},1],"c":{"drop":true}}`, nested.ToJSONString())
+
+	// результат не разделяет объекты с исходным
+	same, err := nested.Transform(func(path []string, node *Nested) (*Nested, error) {
+		return node, nil
+	})
+	if assert.Nil(t, err) {
+		assert.Nil(t, same.SetValue("z", "a", "$ref"))
+		value, _ := nested.GetValue("a", "$ref")
+		assert.Equal(t, "x", value)
+	}
+
+	removed, err := nested.Transform(func(path []string, node *Nested) (*Nested, error) {
+		return nil, nil
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, removed)
+
+	_, err = nested.Transform(func(path []string, node *Nested) (*Nested, error) {
+		if len(path) == 0 {
+			return nil, fmt.Errorf("root")
+		}
+
+		return node, nil
+	})
+	assert.EqualError(t, err, "root")
+}
+
+func Test_Filter(t *testing.T) {
+	nested := FromJSONString(`{"a": 1, "b": null, "c": {"d": null, "e": 2}, "f": [null, 3, {"g": null}]}`)
+
+	paths := []string{}
+	result := nested.Filter(func(path []string, node *Nested) bool {
+		paths = append(paths, strings.Join(path, "."))
+		return !node.IsValue() || node.value != nil
+	})
+
+	assert.Equal(t, `{"a":1,"c":{"e":2},"f":[3,{}]}`, result.ToJSONString())
+	assert.Equal(t, `{"a":1,"b":null,"c":{"d":null,"e":2},"f":[null,3,{"g":null}]}`, nested.ToJSONString())
+
+	// удаленные поддеревья не обходятся
+	pruned := nested.Filter(func(path []string, node *Nested) bool {
+		paths = append(paths, strings.Join(path, "."))
+		return path[0] != "c" && path[0] != "f"
+	})
+
+	assert.Equal(t, `{"a":1,"b":null}`, pruned.ToJSONString())
+	assert.NotContains(t, paths[len(paths)-4:], "c.d")
+}
+
+func Test_Reduce(t *testing.T) {
+	nested := FromJSONString(`{"items": [{"price": 10}, {"price": 15}, {"price": 2.5}]}`)
+
+	total, err := nested.Reduce(func(acc any, element *Nested) (any, error) {
+		price, err := element.GetValue("price")
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := price.(type) {
+		case int:
+			return acc.(float64) + float64(v), nil
+		case float64:
+			return acc.(float64) + v, nil
+		}
+
+		return nil, fmt.Errorf("unexpected price %v", price)
+	}, 0.0, "items")
+
+	if assert.Nil(t, err) {
+		assert.Equal(t, 27.5, total)
+	}
+
+	_, err = FromJSONString(`{"items": [{"price": 1}, {}]}`).Reduce(func(acc any, element *Nested) (any, error) {
+		_, err := element.GetValue("price")
+		return acc, err
+	}, nil, "items")
+	assert.EqualError(t, err, "items.1: is empty")
+
+	count, err := FromJSONString(`[1, 2, 3]`).Reduce(func(acc any, element *Nested) (any, error) {
+		return acc.(int) + 1, nil
+	}, 0)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 3, count)
+	}
+
+	_, err = nested.Reduce(nil, nil, "missing")
+	assert.EqualError(t, err, "key 'missing' not found")
+}
+
+// Индекс строки в срезе или -1.
+func indexOf(values []string, value string) int {
+	for index, v := range values {
+		if v == value {
+			return index
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,521 @@
+package nested
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Соглашение о представлении XML-документа в виде объекта.
+type XMLConvention int
+
+const (
+	// Атрибуты записываются ключами с префиксом "@", текст элемента - ключом "#text".
+	// Элемент без атрибутов и дочерних элементов становится строковым значением, пустой элемент - значением nil.
+	XMLPrefixed XMLConvention = iota
+	// Соглашение BadgerFish: каждый элемент становится объектом ключ-значение, атрибуты записываются ключами
+	// с префиксом "@", текст - ключом "$", объявления пространств имен - объектом "@xmlns",
+	// где ключ "$" соответствует пространству имен по умолчанию.
+	XMLBadgerFish
+	// Соглашение Parker: атрибуты отбрасываются, корневой элемент опускается, текст элементов без дочерних элементов
+	// становится значением с определением типа (целые числа, дробные числа, true и false).
+	XMLParker
+)
+
+// Параметры конвертации между XML и объектом.
+type XMLOptions struct {
+	Convention XMLConvention // соглашение о представлении документа
+
+	// Удаление префиксов пространств имен из имен элементов и атрибутов при разборе,
+	// объявления пространств имен (xmlns) при этом отбрасываются.
+	// По умолчанию префиксы сохраняются в именах ("soap:Envelope"), а объявления - в атрибутах.
+	StripNamespaces bool
+
+	Root string // имя корневого элемента для соглашения Parker при записи, по умолчанию "root"
+}
+
+// Создание объекта из XML-строки.
+//
+// Повторяющиеся дочерние элементы с одинаковыми именами объединяются в массив, порядок разных элементов не сохраняется.
+// Текст вне элементов, комментарии и инструкции обработки игнорируются, текст элементов очищается от пробелов по краям.
+// Представление атрибутов, текста и пространств имен зависит от соглашения в options (см. [XMLConvention]).
+//
+// Для соглашений [XMLPrefixed] и [XMLBadgerFish] результат содержит один ключ - имя корневого элемента.
+//
+// Пример:
+//
+//	nested, err := FromXML(`<user id="1"><name>Alice</name><role>admin</role><role>dev</role></user>`, XMLOptions{})
+//
+//	nested.GetValue("user", "@id")   // "1", nil
+//	nested.GetValue("user", "name")  // "Alice", nil
+//	nested.GetArray("user", "role")  // ["admin", "dev"], nil
+func FromXML(data string, options XMLOptions) (*Nested, error) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+
+	var (
+		stack  []*xmlFrame
+		result *Nested
+	)
+
+	for {
+		// RawToken сохраняет префиксы пространств имен, поэтому парность тегов проверяется вручную
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := decoder.InputPos()
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && result != nil {
+				return nil, fmt.Errorf("line %d: expected single root element", line)
+			}
+
+			stack = append(stack, newXMLFrame(token, options))
+		case xml.EndElement:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: unexpected </%s>", line, xmlName(token.Name, false))
+			}
+
+			frame := stack[len(stack)-1]
+			if name := xmlName(token.Name, false); name != frame.rawName {
+				return nil, fmt.Errorf("line %d: element <%s> closed by </%s>", line, frame.rawName, name)
+			}
+
+			stack = stack[:len(stack)-1]
+			element := frame.build(options.Convention)
+
+			if len(stack) == 0 {
+				result = element
+				if options.Convention != XMLParker {
+					result = &Nested{nested: map[string]*Nested{frame.name: element}}
+				}
+				continue
+			}
+
+			stack[len(stack)-1].add(frame.name, element)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(token)
+			} else if len(bytes.TrimSpace(token)) > 0 {
+				return nil, fmt.Errorf("line %d: text outside root element", line)
+			}
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("element <%s> is not closed", stack[len(stack)-1].rawName)
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("missing root element")
+	}
+
+	return result, nil
+}
+
+// Разбираемый элемент XML.
+type xmlFrame struct {
+	name    string // имя элемента с учетом параметров разбора
+	rawName string // имя элемента в документе
+
+	attributes map[string]string
+	namespaces map[string]*Nested
+	children   map[string]*Nested
+	text       strings.Builder
+}
+
+// Создание разбираемого элемента по открывающему тегу.
+func newXMLFrame(token xml.StartElement, options XMLOptions) *xmlFrame {
+	frame := &xmlFrame{
+		name:       xmlName(token.Name, options.StripNamespaces),
+		rawName:    xmlName(token.Name, false),
+		attributes: map[string]string{},
+		namespaces: map[string]*Nested{},
+		children:   map[string]*Nested{},
+	}
+
+	for _, attribute := range token.Attr {
+		isNamespace := attribute.Name.Space == "xmlns" || (attribute.Name.Space == "" && attribute.Name.Local == "xmlns")
+
+		switch {
+		case isNamespace && options.StripNamespaces:
+			continue
+		case isNamespace && options.Convention == XMLBadgerFish:
+			prefix := attribute.Name.Local
+			if attribute.Name.Space == "" {
+				prefix = "$"
+			}
+
+			frame.namespaces[prefix] = &Nested{isValue: true, value: attribute.Value}
+		default:
+			frame.attributes[xmlName(attribute.Name, options.StripNamespaces)] = attribute.Value
+		}
+	}
+
+	return frame
+}
+
+// Добавление дочернего элемента, повторяющиеся элементы объединяются в массив.
+//
+// Элементы никогда не конвертируются в массивы сами по себе, поэтому массив означает ранее найденные повторы.
+func (f *xmlFrame) add(name string, element *Nested) {
+	existing, ok := f.children[name]
+	if !ok {
+		f.children[name] = element
+		return
+	}
+
+	if existing.IsArray() {
+		existing.array = append(existing.array, element)
+		return
+	}
+
+	f.children[name] = &Nested{isArray: true, array: []*Nested{existing, element}}
+}
+
+// Конвертация разобранного элемента в объект по соглашению.
+func (f *xmlFrame) build(convention XMLConvention) *Nested {
+	text := strings.TrimSpace(f.text.String())
+
+	switch convention {
+	case XMLParker:
+		if len(f.children) > 0 {
+			return &Nested{nested: f.children}
+		}
+
+		if text == "" {
+			return &Nested{isValue: true}
+		}
+
+		return &Nested{isValue: true, value: inferValue(text)}
+	case XMLBadgerFish:
+		if len(f.namespaces) > 0 {
+			f.children["@xmlns"] = &Nested{nested: f.namespaces}
+		}
+
+		if text != "" {
+			f.children["$"] = &Nested{isValue: true, value: text}
+		}
+	default:
+		if len(f.children) == 0 && len(f.attributes) == 0 {
+			if text == "" {
+				return &Nested{isValue: true}
+			}
+
+			return &Nested{isValue: true, value: text}
+		}
+
+		if text != "" {
+			f.children["#text"] = &Nested{isValue: true, value: text}
+		}
+	}
+
+	for k, v := range f.attributes {
+		f.children["@"+k] = &Nested{isValue: true, value: v}
+	}
+
+	return &Nested{nested: f.children}
+}
+
+// Имя элемента или атрибута с префиксом пространства имен.
+func xmlName(name xml.Name, stripNamespace bool) string {
+	if name.Space == "" || stripNamespace {
+		return name.Local
+	}
+
+	return name.Space + ":" + name.Local
+}
+
+// Определение типа значения по строке: целое число, дробное число, true, false или строка.
+//
+// Числа распознаются только в формате JSON, поэтому строки вроде "0x10", "1_000" или "NaN" остаются строками.
+func inferValue(s string) any {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || !json.Valid([]byte(s)) {
+		return s
+	}
+
+	if value, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return int(value)
+	}
+
+	if value, err := strconv.ParseFloat(s, 64); err == nil {
+		return value
+	}
+
+	return s
+}
+
+// Конвертация объекта в XML-строку.
+//
+// Выполняет обратное к [FromXML] преобразование по соглашению из options.
+// Для соглашений [XMLPrefixed] и [XMLBadgerFish] объект должен содержать один ключ - имя корневого элемента.
+// Для соглашения [XMLParker] корневой элемент получает имя options.Root.
+//
+// Массивы записываются повторяющимися элементами, значения nil - пустыми элементами.
+// Ключи словарей записываются в алфавитном порядке. Двоичные данные ([]byte) записываются в base64,
+// время (time.Time) - в формате RFC 3339.
+//
+// Возвращает ошибку для массивов внутри массивов, составных значений атрибутов и имен,
+// которые не могут быть именами элементов XML.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"user": {"@id": "1", "name": "Alice", "role": ["admin", "dev"]}}`)
+//
+//	nested.ToXML(XMLOptions{}) // <user id="1"><name>Alice</name><role>admin</role><role>dev</role></user>, nil
+func (j *Nested) ToXML(options XMLOptions) (string, error) {
+	writer := &xmlWriter{buffer: &bytes.Buffer{}, convention: options.Convention}
+
+	if options.Convention == XMLParker {
+		if j.IsArray() {
+			return "", fmt.Errorf("is array")
+		}
+
+		root := options.Root
+		if root == "" {
+			root = "root"
+		}
+
+		if err := writer.writeElement(root, j, []string{}); err != nil {
+			return "", err
+		}
+
+		return writer.buffer.String(), nil
+	}
+
+	if j.IsValue() {
+		return "", fmt.Errorf("is value")
+	}
+
+	if j.IsArray() {
+		return "", fmt.Errorf("is array")
+	}
+
+	if len(j.nested) != 1 {
+		return "", fmt.Errorf("expected single root element, got %d", len(j.nested))
+	}
+
+	for name, element := range j.nested {
+		if element != nil && element.IsArray() {
+			return "", fmt.Errorf("%s: is array", name)
+		}
+
+		if err := writer.writeElement(name, element, []string{name}); err != nil {
+			return "", err
+		}
+	}
+
+	return writer.buffer.String(), nil
+}
+
+// Запись объекта в XML по соглашению.
+type xmlWriter struct {
+	buffer     *bytes.Buffer
+	convention XMLConvention
+}
+
+// Рекурсивная запись элемента.
+//
+// path - цепочка ключей до объекта для сообщений об ошибках.
+func (w *xmlWriter) writeElement(name string, j *Nested, path []string) error {
+	if !isXMLName(name) {
+		return pathError(path, "invalid element name '%s'", name)
+	}
+
+	if j != nil && j.IsArray() {
+		for index, element := range j.array {
+			elementPath := append(slices.Clone(path), strconv.Itoa(index))
+
+			if element != nil && element.IsArray() {
+				return pathError(elementPath, "nested arrays are not supported in XML")
+			}
+
+			if err := w.writeElement(name, element, elementPath); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if j == nil || (j.IsValue() && j.value == nil) {
+		fmt.Fprintf(w.buffer, "<%s/>", name)
+		return nil
+	}
+
+	if j.IsValue() {
+		fmt.Fprintf(w.buffer, "<%s>", name)
+
+		if err := w.writeText(j.value); err != nil {
+			return pathError(path, "%w", err)
+		}
+
+		fmt.Fprintf(w.buffer, "</%s>", name)
+
+		return nil
+	}
+
+	var (
+		text     *Nested
+		children []string
+	)
+
+	fmt.Fprintf(w.buffer, "<%s", name)
+
+	for _, k := range sortedKeys(j.nested) {
+		value := j.nested[k]
+		keyPath := append(slices.Clone(path), k)
+
+		switch {
+		case w.convention == XMLParker:
+			children = append(children, k)
+		case w.convention == XMLBadgerFish && k == "@xmlns":
+			if err := w.writeNamespaces(value, keyPath); err != nil {
+				return err
+			}
+		case strings.HasPrefix(k, "@"):
+			if err := w.writeAttribute(k[1:], value, keyPath); err != nil {
+				return err
+			}
+		case (w.convention == XMLBadgerFish && k == "$") || (w.convention == XMLPrefixed && k == "#text"):
+			if value != nil && !value.IsValue() {
+				return pathError(keyPath, "text must be a scalar")
+			}
+
+			text = value
+		default:
+			children = append(children, k)
+		}
+	}
+
+	if text == nil && len(children) == 0 {
+		w.buffer.WriteString("/>")
+		return nil
+	}
+
+	w.buffer.WriteByte('>')
+
+	if text != nil {
+		if err := w.writeText(text.value); err != nil {
+			return pathError(path, "%w", err)
+		}
+	}
+
+	for _, k := range children {
+		if err := w.writeElement(k, j.nested[k], append(slices.Clone(path), k)); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w.buffer, "</%s>", name)
+
+	return nil
+}
+
+// Запись атрибута элемента.
+func (w *xmlWriter) writeAttribute(name string, j *Nested, path []string) error {
+	if !isXMLName(name) {
+		return pathError(path, "invalid attribute name '%s'", name)
+	}
+
+	if j != nil && !j.IsValue() {
+		return pathError(path, "attribute value must be a scalar")
+	}
+
+	fmt.Fprintf(w.buffer, ` %s="`, name)
+
+	if j != nil {
+		if err := w.writeText(j.value); err != nil {
+			return pathError(path, "%w", err)
+		}
+	}
+
+	w.buffer.WriteByte('"')
+
+	return nil
+}
+
+// Запись объявлений пространств имен из объекта "@xmlns" соглашения BadgerFish.
+func (w *xmlWriter) writeNamespaces(j *Nested, path []string) error {
+	if j == nil || !j.IsNested() {
+		return pathError(path, "namespaces must be an object")
+	}
+
+	for _, prefix := range sortedKeys(j.nested) {
+		name := "xmlns:" + prefix
+		if prefix == "$" {
+			name = "xmlns"
+		}
+
+		if err := w.writeAttribute(name, j.nested[prefix], append(slices.Clone(path), prefix)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Запись скалярного значения в виде экранированного текста.
+func (w *xmlWriter) writeText(value any) error {
+	var text string
+
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case string:
+		text = value
+	case []byte:
+		text = base64.StdEncoding.EncodeToString(value)
+	case time.Time:
+		text = value.Format(time.RFC3339Nano)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		text = fmt.Sprint(value)
+	default:
+		return fmt.Errorf("unsupported type %T", value)
+	}
+
+	return xml.EscapeText(w.buffer, []byte(text))
+}
+
+// Проверка, что строка может быть именем элемента или атрибута XML.
+func isXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for index, r := range name {
+		if unicode.IsLetter(r) || r == '_' || r == ':' {
+			continue
+		}
+
+		if index > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,191 @@
+package nested
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSOAP = `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns="urn:orders">
+	<!-- комментарий -->
+	<soap:Body>
+		<order id="42" status="new">
+			<item sku="a1">2</item>
+			<item sku="b2">1.5</item>
+			<note>Deliver &amp; call</note>
+			<paid>true</paid>
+			<empty/>
+		</order>
+	</soap:Body>
+</soap:Envelope>`
+
+func Test_FromXML(t *testing.T) {
+	nested, err := FromXML(testSOAP, XMLOptions{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, FromJSONString(`{"soap:Envelope": {
+			"@xmlns:soap": "http://www.w3.org/2003/05/soap-envelope",
+			"@xmlns": "urn:orders",
+			"soap:Body": {"order": {
+				"@id": "42",
+				"@status": "new",
+				"item": [{"@sku": "a1", "#text": "2"}, {"@sku": "b2", "#text": "1.5"}],
+				"note": "Deliver & call",
+				"paid": "true",
+				"empty": null
+			}}
+		}}`), nested)
+	}
+
+	nested, err = FromXML(testSOAP, XMLOptions{StripNamespaces: true})
+	if assert.Nil(t, err) {
+		assert.Equal(t, FromJSONString(`{"Envelope": {
+			"Body": {"order": {
+				"@id": "42",
+				"@status": "new",
+				"item": [{"@sku": "a1", "#text": "2"}, {"@sku": "b2", "#text": "1.5"}],
+				"note": "Deliver & call",
+				"paid": "true",
+				"empty": null
+			}}
+		}}`), nested)
+	}
+
+	nested, err = FromXML(testSOAP, XMLOptions{Convention: XMLBadgerFish})
+	if assert.Nil(t, err) {
+		assert.Equal(t, FromJSONString(`{"soap:Envelope": {
+			"@xmlns": {"soap": "http://www.w3.org/2003/05/soap-envelope", "$": "urn:orders"},
+			"soap:Body": {"order": {
+				"@id": "42",
+				"@status": "new",
+				"item": [{"@sku": "a1", "$": "2"}, {"@sku": "b2", "$": "1.5"}],
+				"note": {"$": "Deliver & call"},
+				"paid": {"$": "true"},
+				"empty": {}
+			}}
+		}}`), nested)
+	}
+
+	nested, err = FromXML(testSOAP, XMLOptions{Convention: XMLParker})
+	if assert.Nil(t, err) {
+		assert.Equal(t, FromJSONString(`{
+			"soap:Body": {"order": {
+				"item": [2, 1.5],
+				"note": "Deliver & call",
+				"paid": true,
+				"empty": null
+			}}
+		}`), nested)
+	}
+
+	nested, err = FromXML(`<a>text<b>1</b>more</a>`, XMLOptions{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, FromJSONString(`{"a": {"#text": "textmore", "b": "1"}}`), nested)
+	}
+
+	nested, err = FromXML(`<values><v>0x10</v><v>-1e3</v><v>NaN</v><v>True</v></values>`, XMLOptions{Convention: XMLParker})
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{nested: map[string]*Nested{"v": {isArray: true, array: []*Nested{
+			{isValue: true, value: "0x10"},
+			{isValue: true, value: -1000.0},
+			{isValue: true, value: "NaN"},
+			{isValue: true, value: "True"},
+		}}}}, nested)
+	}
+
+	cases := []struct {
+		data string
+		err  string
+	}{
+		{"", "missing root element"},
+		{"<a></a><b></b>", "line 1: expected single root element"},
+		{"<a></a>text", "line 1: text outside root element"},
+		{"<a>\n<b></a>", "line 2: element <b> closed by </a>"},
+		{"</a>", "line 1: unexpected </a>"},
+		{"<a><b></b>", "element <a> is not closed"},
+		{"<a><b</a>", "XML syntax error on line 1: expected attribute name in element"},
+	}
+
+	for _, c := range cases {
+		_, err := FromXML(c.data, XMLOptions{})
+		assert.EqualError(t, err, c.err, c.data)
+	}
+}
+
+func Test_ToXML(t *testing.T) {
+	nested := FromJSONString(`{"user": {
+		"@id": "1",
+		"name": "Alice <admin>",
+		"role": ["admin", "dev"],
+		"address": {"@city": "Paris", "#text": "main"},
+		"empty": null,
+		"none": {}
+	}}`)
+
+	result, err := nested.ToXML(XMLOptions{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `<user id="1"><address city="Paris">main</address><empty/><name>Alice &lt;admin&gt;</name><none/>`+
+			`<role>admin</role><role>dev</role></user>`, result)
+
+		parsed, err := FromXML(result, XMLOptions{})
+		if assert.Nil(t, err) {
+			nested.nested["user"].nested["none"] = &Nested{isValue: true}
+			assert.Equal(t, nested, parsed)
+		}
+	}
+
+	badgerFish, err := FromXML(testSOAP, XMLOptions{Convention: XMLBadgerFish})
+	if assert.Nil(t, err) {
+		result, err := badgerFish.ToXML(XMLOptions{Convention: XMLBadgerFish})
+		if assert.Nil(t, err) {
+			assert.Equal(t, `<soap:Envelope xmlns="urn:orders" xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body>`+
+				`<order id="42" status="new"><empty/><item sku="a1">2</item><item sku="b2">1.5</item>`+
+				`<note>Deliver &amp; call</note><paid>true</paid></order></soap:Body></soap:Envelope>`, result)
+
+			parsed, err := FromXML(result, XMLOptions{Convention: XMLBadgerFish})
+			if assert.Nil(t, err) {
+				assert.Equal(t, badgerFish, parsed)
+			}
+		}
+	}
+
+	parker := FromJSONString(`{"count": 2, "ok": true, "items": [{"v": 1.5}, {"v": "x"}]}`)
+	parker.nested["at"] = &Nested{isValue: true, value: time.Date(2024, 3, 18, 10, 0, 0, 0, time.UTC)}
+	parker.nested["data"] = &Nested{isValue: true, value: []byte("hi")}
+
+	result, err = parker.ToXML(XMLOptions{Convention: XMLParker, Root: "response"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `<response><at>2024-03-18T10:00:00Z</at><count>2</count><data>aGk=</data>`+
+			`<items><v>1.5</v></items><items><v>x</v></items><ok>true</ok></response>`, result)
+	}
+
+	result, err = (&Nested{isValue: true, value: 1}).ToXML(XMLOptions{Convention: XMLParker})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `<root>1</root>`, result)
+	}
+
+	cases := []struct {
+		nested  *Nested
+		options XMLOptions
+		err     string
+	}{
+		{FromJSONString(`{"a": 1, "b": 2}`), XMLOptions{}, "expected single root element, got 2"},
+		{FromJSONString(`{"a": [1, 2]}`), XMLOptions{}, "a: is array"},
+		{FromJSONString(`[1]`), XMLOptions{}, "is array"},
+		{FromJSONString(`[1]`), XMLOptions{Convention: XMLParker}, "is array"},
+		{&Nested{isValue: true, value: 1}, XMLOptions{}, "is value"},
+		{FromJSONString(`{"a": {"b": [[1]]}}`), XMLOptions{}, "a.b.0: nested arrays are not supported in XML"},
+		{FromJSONString(`{"a": {"1b": 1}}`), XMLOptions{}, "a.1b: invalid element name '1b'"},
+		{FromJSONString(`{"a": {"@b c": 1}}`), XMLOptions{}, "a.@b c: invalid attribute name 'b c'"},
+		{FromJSONString(`{"a": {"@b": [1]}}`), XMLOptions{}, "a.@b: attribute value must be a scalar"},
+		{FromJSONString(`{"a": {"#text": {}}}`), XMLOptions{}, "a.#text: text must be a scalar"},
+		{FromJSONString(`{"a": {"@xmlns": "x"}}`), XMLOptions{Convention: XMLBadgerFish}, "a.@xmlns: namespaces must be an object"},
+		{&Nested{nested: map[string]*Nested{"a": {isValue: true, value: struct{}{}}}}, XMLOptions{}, "a: unsupported type struct {}"},
+	}
+
+	for _, c := range cases {
+		_, err := c.nested.ToXML(c.options)
+		assert.EqualError(t, err, c.err)
+	}
+}
@@ -0,0 +1,329 @@
+package nested
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Создание объекта из YAML-строки с одним документом.
+//
+// Словари YAML становятся объектами ключ-значение, последовательности - массивами, скаляры - значениями.
+// Типы скаляров определяются по правилам YAML 1.2: целые числа сохраняются как int (или uint64 для больших чисел),
+// дробные - как float64, даты - как time.Time, двоичные данные (!!binary) - как []byte.
+// Ключи словарей, не являющиеся строками, приводятся к строке в том виде, в котором записаны в документе.
+//
+// Якоря и ссылки (aliases) раскрываются в копии, ключи слияния (<<) поддерживаются.
+// Количество объектов после раскрытия ссылок ограничено (не больше 10000 или 100 объектов на каждый узел
+// документа), поэтому документы с экспоненциальным раскрытием ("billion laughs") возвращают ошибку.
+// Ошибки содержат номер строки в документе.
+//
+// Пустой документ возвращает пустой объект. Если в строке несколько документов, вернется ошибка,
+// для таких потоков следует использовать [FromYAMLDocuments].
+//
+// Пример:
+//
+//	nested, err := FromYAML("server:\n  port: 8080\n  hosts: [a, b]\n")
+//
+//	nested.GetValue("server", "port") // 8080, nil
+func FromYAML(data string) (*Nested, error) {
+	documents, err := FromYAMLDocuments(strings.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(documents) == 0 {
+		return &Nested{}, nil
+	}
+
+	if len(documents) > 1 {
+		return nil, fmt.Errorf("expected single document, got %d", len(documents))
+	}
+
+	return documents[0], nil
+}
+
+// Чтение всех документов из YAML-потока.
+//
+// Документы разделяются строкой "---". Правила конвертации см. в [FromYAML].
+func FromYAMLDocuments(r io.Reader) ([]*Nested, error) {
+	decoder := yaml.NewDecoder(r)
+
+	documents := []*Nested{}
+
+	for {
+		var node yaml.Node
+
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		nested, err := newYAMLConverter(&node).convert(&node)
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, nested)
+	}
+}
+
+// Ограничения на количество объектов после раскрытия ссылок YAML.
+const (
+	yamlMinNodes        = 10000 // количество объектов, разрешенное для любого документа
+	yamlExpansionFactor = 100   // количество объектов, разрешенное на каждый узел документа
+)
+
+// Конвертер документа YAML в Nested.
+type yamlConverter struct {
+	aliases  map[*yaml.Node]bool // ссылки, раскрываемые в данный момент, для обнаружения циклических ссылок
+	nodes    int                 // количество созданных объектов
+	maxNodes int
+}
+
+// Создание конвертера для документа с ограничением, зависящим от его размера.
+func newYAMLConverter(document *yaml.Node) *yamlConverter {
+	return &yamlConverter{
+		aliases:  map[*yaml.Node]bool{},
+		maxNodes: max(yamlMinNodes, yamlExpansionFactor*countYAMLNodes(document)),
+	}
+}
+
+// Количество узлов документа без раскрытия ссылок.
+func countYAMLNodes(node *yaml.Node) int {
+	count := 1
+	for _, child := range node.Content {
+		count += countYAMLNodes(child)
+	}
+
+	return count
+}
+
+// Рекурсивная конвертация узла YAML в Nested.
+func (c *yamlConverter) convert(node *yaml.Node) (*Nested, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return &Nested{}, nil
+		}
+
+		return c.convert(node.Content[0])
+	case yaml.AliasNode:
+		if c.aliases[node] {
+			return nil, fmt.Errorf("line %d: alias '%s' refers to itself", node.Line, node.Value)
+		}
+
+		c.aliases[node] = true
+		defer delete(c.aliases, node)
+
+		return c.convert(node.Alias)
+	}
+
+	c.nodes++
+	if c.nodes > c.maxNodes {
+		return nil, fmt.Errorf("line %d: document expands to more than %d nodes", node.Line, c.maxNodes)
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		array := []*Nested{}
+
+		for _, element := range node.Content {
+			nested, err := c.convert(element)
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, nested)
+		}
+
+		return &Nested{isArray: true, array: array}, nil
+	case yaml.MappingNode:
+		nested := map[string]*Nested{}
+
+		// ключи слияния применяются до явных ключей, чтобы явные имели приоритет
+		for index := 0; index < len(node.Content); index += 2 {
+			if node.Content[index].Tag == "!!merge" {
+				if err := c.merge(nested, node.Content[index+1]); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for index := 0; index < len(node.Content); index += 2 {
+			key := node.Content[index]
+			if key.Tag == "!!merge" {
+				continue
+			}
+
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: mapping key must be a scalar", key.Line)
+			}
+
+			value, err := c.convert(node.Content[index+1])
+			if err != nil {
+				return nil, err
+			}
+
+			nested[key.Value] = value
+		}
+
+		return &Nested{nested: nested}, nil
+	case yaml.ScalarNode:
+		if node.Tag == "!!binary" {
+			value, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(node.Value), ""))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", node.Line, err.Error())
+			}
+
+			return &Nested{isValue: true, value: value}, nil
+		}
+
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return nil, fmt.Errorf("line %d: %s", node.Line, err.Error())
+		}
+
+		return &Nested{isValue: true, value: value}, nil
+	}
+
+	return nil, fmt.Errorf("line %d: unsupported node kind %d", node.Line, node.Kind)
+}
+
+// Слияние словаря (или последовательности словарей) из ключа << в результат.
+//
+// Уже существующие ключи не перезаписываются.
+func (c *yamlConverter) merge(nested map[string]*Nested, node *yaml.Node) error {
+	merged, err := c.convert(node)
+	if err != nil {
+		return err
+	}
+
+	sources := []*Nested{merged}
+	if merged.IsArray() {
+		sources = merged.array
+	}
+
+	for _, source := range sources {
+		if !source.IsNested() {
+			return fmt.Errorf("line %d: merge value must be a mapping", node.Line)
+		}
+
+		for k, v := range source.nested {
+			if _, ok := nested[k]; !ok {
+				nested[k] = v
+			}
+		}
+	}
+
+	return nil
+}
+
+// Конвертация объекта в YAML-строку.
+//
+// Ключи словарей сортируются в алфавитном порядке, отступ - два пробела.
+// Значения типа []byte записываются как !!binary.
+//
+// Пример:
+//
+//	nested := FromJSONString(`{"server": {"port": 8080, "hosts": ["a", "b"]}}`)
+//
+//	nested.ToYAML()
+//
+//	// server:
+//	//   hosts:
+//	//     - a
+//	//     - b
+//	//   port: 8080
+func (j *Nested) ToYAML() (string, error) {
+	buffer := &bytes.Buffer{}
+
+	if err := ToYAMLDocuments(buffer, j); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// Запись нескольких объектов в YAML-поток в виде отдельных документов, разделенных "---".
+func ToYAMLDocuments(w io.Writer, documents ...*Nested) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	for _, nested := range documents {
+		node, err := toYAMLNode(nested)
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(node); err != nil {
+			return err
+		}
+	}
+
+	return encoder.Close()
+}
+
+// Рекурсивная конвертация объекта в узел YAML.
+func toYAMLNode(j *Nested) (*yaml.Node, error) {
+	if j == nil {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+
+	if j.IsValue() {
+		if value, ok := j.value.([]byte); ok {
+			return &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Tag:   "!!binary",
+				Value: base64.StdEncoding.EncodeToString(value),
+			}, nil
+		}
+
+		node := &yaml.Node{}
+		if err := node.Encode(j.value); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	}
+
+	if j.IsArray() {
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+		for _, element := range j.array {
+			elementNode, err := toYAMLNode(element)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, elementNode)
+		}
+
+		return node, nil
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+	for _, k := range sortedKeys(j.nested) {
+		valueNode, err := toYAMLNode(j.nested[k])
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k},
+			valueNode,
+		)
+	}
+
+	return node, nil
+}
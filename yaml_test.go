@@ -0,0 +1,198 @@
+package nested
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FromYAML(t *testing.T) {
+	nested, err := FromYAML(`
+value: 42
+float: 42.5
+bool: true
+null_value: ~
+string: "42"
+date: 2024-03-18
+binary: !!binary aGVsbG8=
+1: numeric key
+array:
+  - 142
+  - string in array
+nested:
+  array: [{value: 242}]
+`)
+	if assert.Nil(t, err) {
+		assert.Equal(t,
+			&Nested{nested: map[string]*Nested{
+				"value":      {isValue: true, value: 42},
+				"float":      {isValue: true, value: 42.5},
+				"bool":       {isValue: true, value: true},
+				"null_value": {isValue: true, value: nil},
+				"string":     {isValue: true, value: "42"},
+				"date":       {isValue: true, value: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)},
+				"binary":     {isValue: true, value: []byte("hello")},
+				"1":          {isValue: true, value: "numeric key"},
+				"array": {isArray: true, array: []*Nested{
+					{isValue: true, value: 142},
+					{isValue: true, value: "string in array"},
+				}},
+				"nested": {nested: map[string]*Nested{
+					"array": {isArray: true, array: []*Nested{
+						{nested: map[string]*Nested{"value": {isValue: true, value: 242}}},
+					}},
+				}},
+			}},
+			nested,
+		)
+	}
+
+	nested, err = FromYAML(`
+defaults: &defaults
+  host: localhost
+  port: 5432
+extra: &extra
+  timeout: 5
+db:
+  <<: [*defaults, *extra]
+  port: 6432
+copy: *defaults
+`)
+	if assert.Nil(t, err) {
+		assert.Equal(t,
+			`{"copy":{"host":"localhost","port":5432},"db":{"host":"localhost","port":6432,"timeout":5},`+
+				`"defaults":{"host":"localhost","port":5432},"extra":{"timeout":5}}`,
+			nested.ToJSONString(),
+		)
+
+		// раскрытые ссылки не разделяют память
+		copyNested, _ := nested.Get("copy")
+		copyNested.SetValue("remote", "host")
+		value, _ := nested.GetValue("defaults", "host")
+		assert.Equal(t, "localhost", value)
+	}
+
+	nested, err = FromYAML("")
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{}, nested)
+	}
+
+	nested, err = FromYAML("just a string")
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Nested{isValue: true, value: "just a string"}, nested)
+	}
+
+	_, err = FromYAML("a: 1\n---\nb: 2\n")
+	assert.EqualError(t, err, "expected single document, got 2")
+
+	_, err = FromYAML("a:\n  b: [1\n")
+	assert.EqualError(t, err, "yaml: line 1: did not find expected ',' or ']'")
+
+	_, err = FromYAML("a: 1\n? [b]\n: 2\n")
+	assert.EqualError(t, err, "line 2: mapping key must be a scalar")
+
+	_, err = FromYAML("a: 1\nb: &x [1, *x]\n")
+	assert.EqualError(t, err, "line 2: alias 'x' refers to itself")
+
+	_, err = FromYAML("a: 1\nb:\n  <<: 42\n")
+	assert.EqualError(t, err, "line 3: merge value must be a mapping")
+
+	// экспоненциальное раскрытие ссылок ("billion laughs")
+	laughs := "a: &a [lol, lol, lol, lol, lol, lol, lol, lol, lol]\n"
+	for level := 'b'; level <= 'i'; level++ {
+		previous := "*" + string(level-1)
+		laughs += fmt.Sprintf("%c: &%c [%s]\n", level, level, strings.Repeat(previous+", ", 8)+previous)
+	}
+
+	_, err = FromYAML(laughs)
+	assert.EqualError(t, err, "line 1: document expands to more than 10100 nodes")
+
+	// большие документы без ссылок не ограничиваются
+	nested, err = FromYAML("[" + strings.Repeat("1, ", 20000) + "1]")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 20001, nested.Length())
+	}
+}
+
+func Test_FromYAMLDocuments(t *testing.T) {
+	documents, err := FromYAMLDocuments(strings.NewReader("a: 1\n---\n- 2\n---\n3\n"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, []*Nested{
+			{nested: map[string]*Nested{"a": {isValue: true, value: 1}}},
+			{isArray: true, array: []*Nested{{isValue: true, value: 2}}},
+			{isValue: true, value: 3},
+		}, documents)
+	}
+
+	documents, err = FromYAMLDocuments(strings.NewReader(""))
+	if assert.Nil(t, err) {
+		assert.Len(t, documents, 0)
+	}
+
+	_, err = FromYAMLDocuments(strings.NewReader("a: 1\n---\nb: [\n"))
+	assert.EqualError(t, err, "yaml: line 3: did not find expected node content")
+}
+
+func Test_ToYAML(t *testing.T) {
+	nested := testNested()
+
+	data, err := nested.ToYAML()
+	if assert.Nil(t, err) {
+		assert.Equal(t, `array:
+  - 142
+  - string in array
+nested:
+  array:
+    - value: 242
+    - - value: string in nested array
+    - value: 1242
+  nested:
+    value: string in nested
+  value: string
+value: 42
+`, data)
+
+		parsed, err := FromYAML(data)
+		if assert.Nil(t, err) {
+			assert.True(t, Equals(&nested, parsed))
+		}
+	}
+
+	nested = Nested{nested: map[string]*Nested{
+		"string": {isValue: true, value: "42"},
+		"binary": {isValue: true, value: []byte("hello")},
+		"date":   {isValue: true, value: time.Date(2024, 3, 18, 10, 0, 0, 0, time.UTC)},
+		"null":   nil,
+		"empty":  {},
+		"array":  {isArray: true},
+	}}
+
+	data, err = nested.ToYAML()
+	if assert.Nil(t, err) {
+		assert.Equal(t, `array: []
+binary: !!binary aGVsbG8=
+date: 2024-03-18T10:00:00Z
+empty: {}
+"null": null
+string: "42"
+`, data)
+
+		parsed, err := FromYAML(data)
+		if assert.Nil(t, err) {
+			nested.nested["null"] = &Nested{isValue: true}
+			nested.nested["array"] = &Nested{isArray: true, array: []*Nested{}}
+			nested.nested["empty"] = &Nested{nested: map[string]*Nested{}}
+			assert.Equal(t, &nested, parsed)
+		}
+	}
+
+	buffer := &bytes.Buffer{}
+	err = ToYAMLDocuments(buffer, FromJSONString(`{"a": 1}`), FromJSONString(`[2]`))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a: 1\n---\n- 2\n", buffer.String())
+	}
+}